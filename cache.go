@@ -3,10 +3,12 @@ package appie
 type appCache struct {
 	source  Provider
 	appList []AppData
+	appIDs  map[string]AppData // all apps by ID, including those not listed, loaded by a provider when needed
 }
 
 func (c *appCache) clearCache() {
 	c.appList = nil
+	c.appIDs = nil
 }
 
 func (c *appCache) forEachCachedApplication(f func(string, AppData) bool) {
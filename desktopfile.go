@@ -0,0 +1,476 @@
+package appie
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// DesktopFile is a parsed FreeDesktop.org key file, such as a .desktop or .directory file.
+// Groups and the keys within them are kept in the order they appeared in the file.
+type DesktopFile struct {
+	groups   []*DesktopGroup
+	warnings []*DesktopFileError
+}
+
+// DesktopGroup is a named group of keys within a DesktopFile, for example "Desktop Entry".
+type DesktopGroup struct {
+	name, path string
+	line       int
+
+	entries []desktopEntry
+	index   map[string]int
+}
+
+type desktopEntry struct {
+	key, value string
+	line       int
+}
+
+// DesktopFileError describes a problem found in a desktop file and the line where it occurred.
+type DesktopFileError struct {
+	Path string // Path is the file that was being read, if known
+	Line int    // Line is the 1-based line number of the problem, or 0 if it does not relate to a line
+	Msg  string
+}
+
+func (e *DesktopFileError) Error() string {
+	prefix := e.Path
+	if prefix == "" {
+		prefix = "desktop file"
+	}
+	if e.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s", prefix, e.Line, e.Msg)
+	}
+
+	return prefix + ": " + e.Msg
+}
+
+// LoadDesktopFile opens and parses the desktop file at the specified path.
+func LoadDesktopFile(path string) (*DesktopFile, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	parsed, err := ParseDesktopFile(file)
+	if err != nil {
+		var fileErr *DesktopFileError
+		if errors.As(err, &fileErr) {
+			fileErr.Path = path
+		}
+		return nil, err
+	}
+
+	for _, g := range parsed.groups {
+		g.path = path
+	}
+	for _, w := range parsed.warnings {
+		w.Path = path
+	}
+	return parsed, nil
+}
+
+// ParseDesktopFile reads the content of a desktop file following the Desktop Entry specification.
+// Comments and blank lines are skipped, whitespace around the "=" is ignored and both LF and CRLF
+// line endings are supported. Any syntax error is returned as a *DesktopFileError.
+// Duplicate groups are merged and only the first value of a duplicate key is kept, as other parsers
+// tolerate these, and each is reported by Warnings instead.
+func ParseDesktopFile(r io.Reader) (*DesktopFile, error) {
+	file := &DesktopFile{}
+	reader := bufio.NewReader(r)
+
+	var current *DesktopGroup
+	for lineNum := 1; ; lineNum++ {
+		line, readErr := reader.ReadString('\n')
+		if readErr != nil && readErr != io.EOF {
+			return nil, readErr
+		}
+		if line == "" && readErr == io.EOF {
+			break
+		}
+
+		line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+		if lineNum == 1 {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "", trimmed[0] == '#':
+			// comment or blank line
+		case trimmed[0] == '[':
+			group, err := file.parseGroupHeader(trimmed, lineNum)
+			if err != nil {
+				return nil, err
+			}
+			current = group
+		default:
+			if current == nil {
+				return nil, &DesktopFileError{Line: lineNum, Msg: "key found before the first group header"}
+			}
+			warning, err := current.parseEntry(strings.TrimLeft(line, " \t"), lineNum)
+			if err != nil {
+				return nil, err
+			}
+			if warning != nil {
+				file.warnings = append(file.warnings, warning)
+			}
+		}
+
+		if readErr == io.EOF {
+			break
+		}
+	}
+
+	return file, nil
+}
+
+func (d *DesktopFile) parseGroupHeader(line string, lineNum int) (*DesktopGroup, error) {
+	if line[len(line)-1] != ']' {
+		return nil, &DesktopFileError{Line: lineNum, Msg: "unterminated group header"}
+	}
+	name := line[1 : len(line)-1]
+	if name == "" || strings.ContainsAny(name, "[]") {
+		return nil, &DesktopFileError{Line: lineNum, Msg: fmt.Sprintf("invalid group name %q", name)}
+	}
+	for _, r := range name {
+		if r < ' ' || r == 0x7f {
+			return nil, &DesktopFileError{Line: lineNum, Msg: fmt.Sprintf("invalid group name %q", name)}
+		}
+	}
+	if existing := d.Group(name); existing != nil {
+		d.warnings = append(d.warnings, &DesktopFileError{
+			Line: lineNum,
+			Msg:  fmt.Sprintf("duplicate group %q, merged with line %d", name, existing.line),
+		})
+		return existing, nil
+	}
+
+	group := &DesktopGroup{name: name, line: lineNum, index: make(map[string]int)}
+	d.groups = append(d.groups, group)
+	return group, nil
+}
+
+// parseEntry adds a key=value line to the group. Only whitespace around the "=" is removed,
+// as trailing whitespace in a value is significant. A duplicate key is ignored and returned as a warning.
+func (g *DesktopGroup) parseEntry(line string, lineNum int) (warning, err *DesktopFileError) {
+	pos := strings.IndexByte(line, '=')
+	if pos == -1 {
+		return nil, &DesktopFileError{Line: lineNum, Msg: fmt.Sprintf("invalid line %q, expected key=value", line)}
+	}
+
+	key := strings.TrimSpace(line[:pos])
+	if !validDesktopKey(key) {
+		return nil, &DesktopFileError{Line: lineNum, Msg: fmt.Sprintf("invalid key name %q", key)}
+	}
+	if existing, ok := g.index[key]; ok {
+		return &DesktopFileError{
+			Line: lineNum,
+			Msg:  fmt.Sprintf("duplicate key %q ignored, first defined on line %d", key, g.entries[existing].line),
+		}, nil
+	}
+
+	g.index[key] = len(g.entries)
+	g.entries = append(g.entries, desktopEntry{key: key, value: strings.TrimLeft(line[pos+1:], " \t"), line: lineNum})
+	return nil, nil
+}
+
+// validDesktopKey checks that a key is not empty and that any locale suffix is well formed.
+func validDesktopKey(key string) bool {
+	open := strings.IndexByte(key, '[')
+	if open == -1 {
+		return key != "" && !strings.ContainsAny(key, "] \t")
+	}
+
+	return open > 0 && key[len(key)-1] == ']' && len(key)-open > 2 &&
+		!strings.ContainsAny(key[:open], " \t") && !strings.ContainsAny(key[open+1:len(key)-1], "[] \t")
+}
+
+// Warnings returns the problems found while parsing that did not stop the file being loaded,
+// such as duplicate groups or keys.
+func (d *DesktopFile) Warnings() []*DesktopFileError {
+	return d.warnings
+}
+
+// Groups returns all the groups of this file in the order they were defined.
+func (d *DesktopFile) Groups() []*DesktopGroup {
+	return d.groups
+}
+
+// Group returns the group with the specified name, or nil if it is not present.
+func (d *DesktopFile) Group(name string) *DesktopGroup {
+	for _, g := range d.groups {
+		if g.name == name {
+			return g
+		}
+	}
+
+	return nil
+}
+
+// Name returns the name of this group, without the surrounding brackets.
+func (g *DesktopGroup) Name() string {
+	return g.name
+}
+
+// Keys returns the keys of this group in the order they were defined, including localised variants.
+func (g *DesktopGroup) Keys() []string {
+	keys := make([]string, len(g.entries))
+	for i, e := range g.entries {
+		keys[i] = e.key
+	}
+	return keys
+}
+
+// Has returns true if the key is defined in this group.
+func (g *DesktopGroup) Has(key string) bool {
+	_, ok := g.index[key]
+	return ok
+}
+
+// Value returns the raw value for a key, before any escape sequences are decoded, and whether it was found.
+func (g *DesktopGroup) Value(key string) (string, bool) {
+	i, ok := g.index[key]
+	if !ok {
+		return "", false
+	}
+
+	return g.entries[i].value, true
+}
+
+// String returns the value of a string key with escape sequences decoded.
+// If the key is not present an empty string is returned.
+func (g *DesktopGroup) String(key string) string {
+	val, _ := g.Value(key)
+	return unescapeDesktopValue(val, false)
+}
+
+// LocaleString returns the value of a localestring key that best matches the locale requested.
+// The locale is in the form lang_COUNTRY.ENCODING@MODIFIER, where all but lang are optional,
+// and is matched following the Desktop Entry specification before falling back to the unlocalised key.
+func (g *DesktopGroup) LocaleString(key, locale string) string {
+	return unescapeDesktopValue(g.localeValue(key, locale), false)
+}
+
+// Bool returns the value of a boolean key. A missing key is reported as false.
+// Values other than "true" or "false" (or the legacy "1" and "0") return a *DesktopFileError.
+func (g *DesktopGroup) Bool(key string) (bool, error) {
+	i, ok := g.index[key]
+	if !ok {
+		return false, nil
+	}
+
+	switch g.entries[i].value {
+	case "true", "1":
+		return true, nil
+	case "false", "0":
+		return false, nil
+	}
+	return false, g.valueError(i, "boolean")
+}
+
+// Number returns the value of a numeric key. A missing key is reported as 0.
+// Values that cannot be parsed as a number return a *DesktopFileError.
+func (g *DesktopGroup) Number(key string) (float64, error) {
+	i, ok := g.index[key]
+	if !ok {
+		return 0, nil
+	}
+
+	num, err := strconv.ParseFloat(g.entries[i].value, 64)
+	if err != nil {
+		return 0, g.valueError(i, "numeric")
+	}
+	return num, nil
+}
+
+// StringList returns the items of a semicolon separated list key with escape sequences decoded.
+// Empty items, such as the one after a trailing semicolon, are skipped.
+func (g *DesktopGroup) StringList(key string) []string {
+	val, _ := g.Value(key)
+	return splitDesktopList(val)
+}
+
+// LocaleStringList returns the items of a localised list key, such as Keywords, that best match the locale.
+func (g *DesktopGroup) LocaleStringList(key, locale string) []string {
+	return splitDesktopList(g.localeValue(key, locale))
+}
+
+func (g *DesktopGroup) localeValue(key, locale string) string {
+	for _, variant := range localeVariants(locale) {
+		if val, ok := g.Value(key + "[" + variant + "]"); ok {
+			return val
+		}
+	}
+
+	val, _ := g.Value(key)
+	return val
+}
+
+func (g *DesktopGroup) valueError(i int, kind string) error {
+	e := g.entries[i]
+	return &DesktopFileError{
+		Path: g.path, Line: e.line,
+		Msg: fmt.Sprintf("invalid %s value %q for key %q", kind, e.value, e.key),
+	}
+}
+
+// localeVariants returns the locale keys to look up, most specific first, for a locale string
+// in the form lang_COUNTRY.ENCODING@MODIFIER. The encoding is ignored for matching.
+func localeVariants(locale string) []string {
+	if locale == "" {
+		return nil
+	}
+
+	var modifier, country string
+	if pos := strings.IndexByte(locale, '@'); pos != -1 {
+		modifier = locale[pos+1:]
+		locale = locale[:pos]
+	}
+	if pos := strings.IndexByte(locale, '.'); pos != -1 {
+		locale = locale[:pos]
+	}
+	lang := locale
+	if pos := strings.IndexByte(locale, '_'); pos != -1 {
+		country = locale[pos+1:]
+		lang = locale[:pos]
+	}
+
+	var variants []string
+	if country != "" && modifier != "" {
+		variants = append(variants, lang+"_"+country+"@"+modifier)
+	}
+	if country != "" {
+		variants = append(variants, lang+"_"+country)
+	}
+	if modifier != "" {
+		variants = append(variants, lang+"@"+modifier)
+	}
+	return append(variants, lang)
+}
+
+// unescapeDesktopValue decodes the \s, \n, \t, \r and \\ escape sequences of a value.
+// If list is true then \; is also decoded, as it is only valid within a list.
+func unescapeDesktopValue(val string, list bool) string {
+	if !strings.ContainsRune(val, '\\') {
+		return val
+	}
+
+	var out strings.Builder
+	for i := 0; i < len(val); i++ {
+		if val[i] != '\\' || i == len(val)-1 {
+			out.WriteByte(val[i])
+			continue
+		}
+
+		i++
+		switch val[i] {
+		case 's':
+			out.WriteByte(' ')
+		case 'n':
+			out.WriteByte('\n')
+		case 't':
+			out.WriteByte('\t')
+		case 'r':
+			out.WriteByte('\r')
+		case '\\':
+			out.WriteByte('\\')
+		case ';':
+			if !list {
+				out.WriteByte('\\')
+			}
+			out.WriteByte(';')
+		default:
+			out.WriteByte('\\')
+			out.WriteByte(val[i])
+		}
+	}
+	return out.String()
+}
+
+// splitDesktopList splits a list value on any semicolons that are not escaped and decodes each item.
+func splitDesktopList(val string) []string {
+	var items []string
+	start := 0
+	for i := 0; i < len(val); i++ {
+		switch val[i] {
+		case '\\':
+			i++ // skip the escaped character
+		case ';':
+			if i > start {
+				items = append(items, unescapeDesktopValue(val[start:i], true))
+			}
+			start = i + 1
+		}
+	}
+	if start < len(val) {
+		items = append(items, unescapeDesktopValue(val[start:], true))
+	}
+
+	return items
+}
+
+// SplitExec splits the value of an Exec key into its arguments following the Desktop Entry quoting rules.
+// Arguments are separated by spaces and may be quoted with double quotes, inside which a backslash
+// must escape one of '"', '`', '$' and '\'. Outside of quotes a backslash escapes the next character.
+// Like GLib, single quotes are accepted with their content used literally, and the reserved shell
+// characters, such as `, $ and |, are accepted without quoting.
+// Field codes, such as %f, are returned unchanged. Malformed values return an error.
+func SplitExec(execLine string) ([]string, error) {
+	var args []string
+	var arg strings.Builder
+	inArg, quoted, singleQuoted := false, false, false
+	for i := 0; i < len(execLine); i++ {
+		c := execLine[i]
+		switch {
+		case singleQuoted && c == '\'':
+			singleQuoted = false
+		case singleQuoted:
+			arg.WriteByte(c)
+		case quoted && c == '"':
+			quoted = false
+		case quoted && c == '\\':
+			if i == len(execLine)-1 || strings.IndexByte("\"`$\\", execLine[i+1]) == -1 {
+				return nil, fmt.Errorf("invalid Exec value %q: invalid escape inside quotes", execLine)
+			}
+			i++
+			arg.WriteByte(execLine[i])
+		case quoted:
+			arg.WriteByte(c)
+		case c == ' ' || c == '\t' || c == '\n':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		case c == '"':
+			quoted, inArg = true, true
+		case c == '\'':
+			singleQuoted, inArg = true, true
+		case c == '\\':
+			if i == len(execLine)-1 {
+				return nil, fmt.Errorf("invalid Exec value %q: trailing backslash", execLine)
+			}
+			i++
+			arg.WriteByte(execLine[i])
+			inArg = true
+		default:
+			arg.WriteByte(c)
+			inArg = true
+		}
+	}
+
+	if quoted || singleQuoted {
+		return nil, fmt.Errorf("invalid Exec value %q: unterminated quote", execLine)
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args, nil
+}
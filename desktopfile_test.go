@@ -0,0 +1,186 @@
+package appie
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDesktopFile(t *testing.T) {
+	content := "\ufeff# A comment\r\n" +
+		"[Desktop Entry]\r\n" +
+		"Name = My App\r\n" +
+		"   # indented comment\r\n" +
+		"\r\n" +
+		"Exec=env A=b app --flag\r\n" +
+		"[Desktop Action new]\r\n" +
+		"Name=New Window\r\n"
+	file, err := ParseDesktopFile(strings.NewReader(content))
+	assert.Nil(t, err)
+
+	groups := file.Groups()
+	assert.Equal(t, 2, len(groups))
+	assert.Equal(t, "Desktop Entry", groups[0].Name())
+	assert.Equal(t, []string{"Name", "Exec"}, groups[0].Keys())
+	assert.Equal(t, "My App", groups[0].String("Name"))
+	assert.Equal(t, "env A=b app --flag", groups[0].String("Exec"))
+	assert.Equal(t, "New Window", file.Group("Desktop Action new").String("Name"))
+	assert.Nil(t, file.Group("Missing"))
+}
+
+func TestParseDesktopFile_LongLine(t *testing.T) {
+	long := strings.Repeat("x", 100*1024)
+	file, err := ParseDesktopFile(strings.NewReader("[Desktop Entry]\nComment=" + long + "\nName=Long"))
+	assert.Nil(t, err)
+
+	entry := file.Group("Desktop Entry")
+	assert.Equal(t, long, entry.String("Comment"))
+	assert.Equal(t, "Long", entry.String("Name"))
+}
+
+func TestParseDesktopFile_Errors(t *testing.T) {
+	for name, tt := range map[string]struct {
+		content string
+		line    int
+	}{
+		"no group":       {"# comment\nName=A\n", 2},
+		"bad header":     {"[Desktop Entry\n", 1},
+		"missing equals": {"[Desktop Entry]\nName\n", 2},
+		"bad locale":     {"[Desktop Entry]\nName[=A\n", 2},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseDesktopFile(strings.NewReader(tt.content))
+			var fileErr *DesktopFileError
+			if assert.True(t, errors.As(err, &fileErr)) {
+				assert.Equal(t, tt.line, fileErr.Line)
+			}
+		})
+	}
+}
+
+func TestParseDesktopFile_Duplicates(t *testing.T) {
+	file, err := ParseDesktopFile(strings.NewReader("[Desktop Entry]\nName=A\nName=B\n\n[Desktop Entry]\nExec=app\n"))
+	assert.Nil(t, err)
+
+	assert.Equal(t, 1, len(file.Groups()))
+	entry := file.Group("Desktop Entry")
+	assert.Equal(t, "A", entry.String("Name"))
+	assert.Equal(t, "app", entry.String("Exec"))
+
+	warnings := file.Warnings()
+	assert.Equal(t, 2, len(warnings))
+	assert.Equal(t, 3, warnings[0].Line)
+	assert.Equal(t, 5, warnings[1].Line)
+}
+
+func TestParseDesktopFile_TrailingSpace(t *testing.T) {
+	file, err := ParseDesktopFile(strings.NewReader("[Desktop Entry]\n  Name = My App  \nComment=Ends with space\\s\n"))
+	assert.Nil(t, err)
+
+	entry := file.Group("Desktop Entry")
+	assert.Equal(t, "My App  ", entry.String("Name"))
+	assert.Equal(t, "Ends with space ", entry.String("Comment"))
+}
+
+func TestDesktopGroup_String(t *testing.T) {
+	file, err := ParseDesktopFile(strings.NewReader(
+		`[Desktop Entry]
+Comment=Line\nTab\tSpace\sSlash\\Semi\;
+Raw=\q
+`))
+	assert.Nil(t, err)
+
+	entry := file.Group("Desktop Entry")
+	assert.Equal(t, "Line\nTab\tSpace Slash\\Semi\\;", entry.String("Comment"))
+	assert.Equal(t, `\q`, entry.String("Raw"))
+	raw, ok := entry.Value("Comment")
+	assert.True(t, ok)
+	assert.Equal(t, `Line\nTab\tSpace\sSlash\\Semi\;`, raw)
+}
+
+func TestDesktopGroup_LocaleString(t *testing.T) {
+	file, err := ParseDesktopFile(strings.NewReader(`[Desktop Entry]
+Name=Default
+Name[de]=German
+Name[de_AT]=Austrian
+Name[sr@latin]=Serbian Latin
+Keywords=one;two;
+Keywords[de]=eins;zwei\;drei;
+`))
+	assert.Nil(t, err)
+
+	entry := file.Group("Desktop Entry")
+	assert.Equal(t, "Default", entry.LocaleString("Name", ""))
+	assert.Equal(t, "Default", entry.LocaleString("Name", "fr_FR.UTF-8"))
+	assert.Equal(t, "German", entry.LocaleString("Name", "de_DE.UTF-8"))
+	assert.Equal(t, "Austrian", entry.LocaleString("Name", "de_AT.UTF-8@euro"))
+	assert.Equal(t, "Serbian Latin", entry.LocaleString("Name", "sr_RS@latin"))
+
+	assert.Equal(t, []string{"one", "two"}, entry.LocaleStringList("Keywords", "en_GB"))
+	assert.Equal(t, []string{"eins", "zwei;drei"}, entry.LocaleStringList("Keywords", "de"))
+}
+
+func TestDesktopGroup_TypedValues(t *testing.T) {
+	file, err := ParseDesktopFile(strings.NewReader(`[Desktop Entry]
+Terminal=true
+NoDisplay=false
+Broken=yes
+Version=1.5
+Bad=one
+Categories=A;;B;C
+`))
+	assert.Nil(t, err)
+	entry := file.Group("Desktop Entry")
+
+	b, err := entry.Bool("Terminal")
+	assert.Nil(t, err)
+	assert.True(t, b)
+	b, err = entry.Bool("NoDisplay")
+	assert.Nil(t, err)
+	assert.False(t, b)
+	b, err = entry.Bool("Missing")
+	assert.Nil(t, err)
+	assert.False(t, b)
+	_, err = entry.Bool("Broken")
+	var fileErr *DesktopFileError
+	if assert.True(t, errors.As(err, &fileErr)) {
+		assert.Equal(t, 4, fileErr.Line)
+	}
+
+	num, err := entry.Number("Version")
+	assert.Nil(t, err)
+	assert.Equal(t, 1.5, num)
+	_, err = entry.Number("Bad")
+	assert.NotNil(t, err)
+
+	assert.Equal(t, []string{"A", "B", "C"}, entry.StringList("Categories"))
+	assert.Nil(t, entry.StringList("Missing"))
+}
+
+func TestSplitExec(t *testing.T) {
+	for execLine, args := range map[string][]string{
+		"app":                           {"app"},
+		"app  --flag   %U":              {"app", "--flag", "%U"},
+		`sh -c "echo hi; sleep 1"`:      {"sh", "-c", "echo hi; sleep 1"},
+		`"/opt/My App/run" %f`:          {"/opt/My App/run", "%f"},
+		`app "" "say \"hi\" \$HOME \\"`: {"app", "", `say "hi" $HOME \`},
+		"sh -c 'foo; bar' 'a \\'b":      {"sh", "-c", "foo; bar", `a \b`},
+		"app $HOME | grep `x` > out":    {"app", "$HOME", "|", "grep", "`x`", ">", "out"},
+		`env WINEPREFIX="/home/u/.wine" wine C:\\windows\\start.exe /Unix /home/u/My\ Game.lnk`: {
+			"env", "WINEPREFIX=/home/u/.wine", "wine", `C:\windows\start.exe`, "/Unix", "/home/u/My Game.lnk",
+		},
+	} {
+		split, err := SplitExec(execLine)
+		assert.Nil(t, err, execLine)
+		assert.Equal(t, args, split, execLine)
+	}
+}
+
+func TestSplitExec_Errors(t *testing.T) {
+	for _, execLine := range []string{`app "unterminated`, `app trailing\`, "sh -c 'unterminated", `app "bad \a escape"`} {
+		_, err := SplitExec(execLine)
+		assert.NotNil(t, err, execLine)
+	}
+}
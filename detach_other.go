@@ -0,0 +1,8 @@
+//go:build !unix && !windows
+
+package appie
+
+import "os/exec"
+
+// detachCmd does nothing on platforms that do not have sessions or process groups
+func detachCmd(_ *exec.Cmd) {}
@@ -0,0 +1,14 @@
+//go:build unix
+
+package appie
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// detachCmd starts the command in a new session, so that it has no controlling terminal and
+// will not receive signals sent to the process group of the launcher
+func detachCmd(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+}
@@ -0,0 +1,25 @@
+//go:build unix
+
+package appie
+
+import (
+	"context"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecLauncher_Detach(t *testing.T) {
+	p, err := ExecLauncher{Detach: true}.Launch(context.Background(), &Command{Args: []string{"/bin/sh", "-c", "sleep 10"}})
+	assert.Nil(t, err)
+	defer func() {
+		_ = p.Kill()
+		_ = p.Wait()
+	}()
+
+	// a new session also starts a new process group led by the app
+	pgid, err := syscall.Getpgid(p.PID())
+	assert.Nil(t, err)
+	assert.Equal(t, p.PID(), pgid)
+}
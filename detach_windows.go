@@ -0,0 +1,17 @@
+//go:build windows
+
+package appie
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// windowsDetachedProcess is the DETACHED_PROCESS creation flag, so the app does not share our console
+const windowsDetachedProcess = 0x00000008
+
+// detachCmd starts the command in a new process group without our console, so that it will not
+// receive the console events, such as Ctrl+C, that are sent to the launcher
+func detachCmd(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP | windowsDetachedProcess}
+}
@@ -2,11 +2,16 @@ package appie
 
 import (
 	"bufio"
+	"context"
+	"errors"
+	"fmt"
 	"io/fs"
 	"math"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
 	"strconv"
 	"strings"
 
@@ -15,6 +20,15 @@ import (
 	_ "github.com/fyne-io/image/xpm" // load XPM icons to supported image format
 )
 
+const (
+	// fdoOpenCommand is used to open URLs with the default handler
+	fdoOpenCommand = "xdg-open"
+
+	// fdoLaunchedPIDScript exports GIO_LAUNCHED_DESKTOP_FILE_PID with the PID of the shell before
+	// replacing the shell with the command passed as its arguments
+	fdoLaunchedPIDScript = `GIO_LAUNCHED_DESKTOP_FILE_PID=$$; export GIO_LAUNCHED_DESKTOP_FILE_PID; exec "$@"`
+)
+
 var (
 	iconExtensions      = []string{".png", ".svg", ".xpm"}
 	fallbackCategory    = "Other"
@@ -26,35 +40,96 @@ var (
 
 // fdoApplicationData is a structure that contains information about .desktop files
 type fdoApplicationData struct {
-	name     string // Application name
+	name     string // Application name, translated for the locale
+	baseName string // Untranslated application name, used to look up apps independently of the locale
 	iconName string // Icon name
 	iconPath string // Icon path
 	exec     string // Command to execute application
-
-	categories, mime []string
-	hide             bool
-	iconCache        fyne.Resource
-
-	source  *AppSource
-	actions []Action
+	tryExec  string // Executable used to check that the application is installed
+	id       string // Desktop file ID
+	path     string // Location of the desktop file
+	kind     AppType
+	url      string // URL to open for a Link entry
+	wmClass  string // Window class that the application's windows will have
+	workDir  string // Working directory to run the application in
+
+	terminal        bool   // Whether the application should run in a terminal
+	terminalArgExec string // Argument used to run a command if this application is a terminal
+
+	genericName, comment string
+	keywords             []string
+
+	categories, mime      []string
+	onlyShowIn, notShowIn []string
+	noDisplay, deleted    bool
+	iconCache             fyne.Resource
+
+	source   *AppSource
+	actions  []Action
+	provider *fdoIconProvider
 }
 
 func (data *fdoApplicationData) Actions() []Action {
 	return data.actions
 }
 
-// Name returns the name associated with an fdo app
+// Name returns the name associated with an fdo app, translated to the current locale if available
 func (data *fdoApplicationData) Name() string {
 	return data.name
 }
 
+// GenericName returns the generic name of an fdo app, such as "Web Browser"
+func (data *fdoApplicationData) GenericName() string {
+	return data.genericName
+}
+
+// Comment returns the tooltip description of an fdo app
+func (data *fdoApplicationData) Comment() string {
+	return data.comment
+}
+
+// Keywords returns the list of search keywords an fdo app has configured
+func (data *fdoApplicationData) Keywords() []string {
+	return data.keywords
+}
+
+// Type returns the type of this desktop entry, entries without a Type are treated as applications
+func (data *fdoApplicationData) Type() AppType {
+	return data.kind
+}
+
+// ID returns the desktop file ID of an fdo app, for example org.gnome.Nautilus.desktop
+func (data *fdoApplicationData) ID() string {
+	return data.id
+}
+
 // Categories returns a list of the categories this icon has configured
 func (data *fdoApplicationData) Categories() []string {
 	return data.categories
 }
 
+// Hidden returns true if the app should not be shown, either because it is NoDisplay or it was deleted
 func (data *fdoApplicationData) Hidden() bool {
-	return data.hide
+	return data.noDisplay || data.deleted
+}
+
+// NoDisplay returns true if the app should not be shown in menus but can still be launched
+func (data *fdoApplicationData) NoDisplay() bool {
+	return data.noDisplay
+}
+
+// Installed returns true unless the app has a TryExec key and that executable cannot be found
+func (data *fdoApplicationData) Installed() bool {
+	if data.tryExec == "" {
+		return true
+	}
+
+	if filepath.IsAbs(data.tryExec) {
+		info, err := os.Stat(data.tryExec)
+		return err == nil && !info.IsDir() && info.Mode()&0o111 != 0
+	}
+	_, err := exec.LookPath(data.tryExec)
+	return err == nil
 }
 
 // IconName returns the name of the icon that an fdo app wishes to use
@@ -84,61 +159,444 @@ func (data *fdoApplicationData) MimeTypes() []string {
 	return data.mime
 }
 
+// WorkingDir returns the directory from the Path key that the app should run in, with "~" expanded to the home directory
+func (data *fdoApplicationData) WorkingDir() string {
+	if data.workDir != "~" && !strings.HasPrefix(data.workDir, "~/") {
+		return data.workDir
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return data.workDir
+	}
+	return filepath.Join(home, data.workDir[1:])
+}
+
+// launchDir returns the working directory for launching this app, or an error if it does not exist
+func (data *fdoApplicationData) launchDir() (string, error) {
+	dir := data.WorkingDir()
+	if dir == "" || data.kind != TypeApplication {
+		return "", nil
+	}
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		return "", fmt.Errorf("working directory for %s: %w", data.name, err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("working directory for %s is not a directory: %s", data.name, dir)
+	}
+	return dir, nil
+}
+
 func (data *fdoApplicationData) Source() *AppSource {
 	return data.source
 }
 
+// fdoFieldCodes holds the values used to expand the field codes of an Exec key
+type fdoFieldCodes struct {
+	icon, name, location string
+
+	params     []string
+	paramIndex int
+}
+
 // extractArgs sanitises argument parameters from an Exec configuration
 func extractArgs(args, params []string) []string {
-	paramIndex := 0
+	codes := &fdoFieldCodes{}
+	return codes.expand(args, params)
+}
+
+// expand replaces the field codes in args, consuming params for any file or URL codes.
+// Field codes that are deprecated or have no value to insert are removed.
+func (c *fdoFieldCodes) expand(args, params []string) []string {
+	c.params, c.paramIndex = params, 0
 	var ret []string
 	for _, arg := range args {
-		if len(arg) >= 2 && arg[0] == '%' {
-			switch arg[1] {
-			case 'u', 'f': // url, file
-				if paramIndex < len(params) {
-					ret = append(ret, params[paramIndex])
-					paramIndex++
-				}
-			case 'U', 'F': // url list, file list
-				if paramIndex < len(params) {
-					ret = append(ret, params[paramIndex:]...)
-					paramIndex = len(params)
-				}
-			}
+		if len(arg) == 2 && arg[0] == '%' && arg[1] != '%' {
+			ret = append(ret, c.expandArg(arg[1])...)
 			continue
 		}
-		ret = append(ret, arg)
+
+		ret = append(ret, c.expandEmbedded(arg))
 	}
 
 	return ret
 }
 
+// expandArg returns the arguments that replace a field code that was a whole argument
+func (c *fdoFieldCodes) expandArg(code byte) []string {
+	switch code {
+	case 'f', 'u': // file, url
+		if c.paramIndex < len(c.params) {
+			c.paramIndex++
+			return []string{c.convert(code, c.params[c.paramIndex-1])}
+		}
+	case 'F', 'U': // file list, url list
+		var list []string
+		for ; c.paramIndex < len(c.params); c.paramIndex++ {
+			list = append(list, c.convert(code, c.params[c.paramIndex]))
+		}
+		return list
+	case 'i': // icon
+		if c.icon != "" {
+			return []string{"--icon", c.icon}
+		}
+	case 'c', 'k': // translated name, desktop file location
+		if val := c.expandEmbedded("%" + string(code)); val != "" {
+			return []string{val}
+		}
+	}
+
+	return nil // deprecated or unknown field codes are removed
+}
+
+// expandEmbedded replaces the field codes that may appear within another argument
+func (c *fdoFieldCodes) expandEmbedded(arg string) string {
+	if !strings.ContainsRune(arg, '%') {
+		return arg
+	}
+
+	var out strings.Builder
+	for i := 0; i < len(arg); i++ {
+		if arg[i] != '%' || i == len(arg)-1 {
+			out.WriteByte(arg[i])
+			continue
+		}
+
+		i++
+		switch arg[i] {
+		case '%':
+			out.WriteByte('%')
+		case 'f', 'u':
+			if c.paramIndex < len(c.params) {
+				out.WriteString(c.convert(arg[i], c.params[c.paramIndex]))
+				c.paramIndex++
+			}
+		case 'c':
+			out.WriteString(c.name)
+		case 'k':
+			out.WriteString(c.location)
+		}
+	}
+	return out.String()
+}
+
+// instances expands the field codes of args for each instance of the app that should be started.
+// If the Exec only accepts a single file or URL then one instance is started for each parameter.
+func (c *fdoFieldCodes) instances(args, params []string) [][]string {
+	if len(params) <= 1 || !fdoSingleParamExec(args) {
+		return [][]string{c.expand(args, params)}
+	}
+
+	lines := make([][]string, len(params))
+	for i, param := range params {
+		lines[i] = c.expand(args, []string{param})
+	}
+	return lines
+}
+
+// fdoSingleParamExec returns true if the Exec arguments contain a %f or %u code and no list codes
+func fdoSingleParamExec(args []string) bool {
+	single := false
+	for _, arg := range args {
+		for i := 0; i < len(arg)-1; i++ {
+			if arg[i] != '%' {
+				continue
+			}
+
+			i++
+			switch arg[i] {
+			case 'F', 'U':
+				return false
+			case 'f', 'u':
+				single = true
+			}
+		}
+	}
+	return single
+}
+
+// convert returns a parameter in the form required by a field code.
+// File codes need local paths and URL codes are passed local files as file:// URIs.
+func (c *fdoFieldCodes) convert(code byte, param string) string {
+	if code == 'u' || code == 'U' {
+		return fdoFileURI(param)
+	}
+
+	return fdoLocalPath(param)
+}
+
+// fdoFileURI returns a file:// URI for an absolute local path, other values are returned unchanged
+func fdoFileURI(param string) string {
+	if !filepath.IsAbs(param) {
+		return param
+	}
+
+	u := &url.URL{Scheme: "file", Path: filepath.ToSlash(param)}
+	if u.Path[0] != '/' {
+		u.Path = "/" + u.Path // Windows paths start with a drive letter
+	}
+	return u.String()
+}
+
+// fdoLocalPath returns the local path for a file:// URI, other values are returned unchanged
+func fdoLocalPath(param string) string {
+	if !strings.HasPrefix(param, "file://") {
+		return param
+	}
+
+	u, err := url.Parse(param)
+	if err != nil || (u.Host != "" && u.Host != "localhost") {
+		return param
+	}
+	path := u.Path
+	if len(path) > 2 && path[0] == '/' && path[2] == ':' {
+		path = path[1:] // Windows paths start with a drive letter
+	}
+	return filepath.FromSlash(path)
+}
+
+// fieldCodes returns the values for this app that are used to expand Exec field codes
+func (data *fdoApplicationData) fieldCodes() *fdoFieldCodes {
+	return &fdoFieldCodes{icon: data.iconName, name: data.name, location: data.path}
+}
+
 // Run executes the command for this fdo app.
 // It also passes in the specified environment variables
 func (data *fdoApplicationData) Run(env []string) error {
 	return data.RunWithParameters([]string{}, env)
 }
 
+// RunContext executes the command for this fdo app, giving up if the context ends before it starts
+func (data *fdoApplicationData) RunContext(ctx context.Context, env []string) error {
+	return data.RunWithParametersContext(ctx, []string{}, env)
+}
+
 // RunWithParameters executes the command for this fdo app.
 // It passes any parameters specified and sets up the listed environment.
+// If the app can only open one file or URL at a time then an instance is started for each parameter,
+// and if any fail to start a LaunchErrors listing each failure is returned.
 func (data *fdoApplicationData) RunWithParameters(params, env []string) error {
-	vars := os.Environ()
-	vars = append(vars, env...)
+	return data.RunWithParametersContext(context.Background(), params, env)
+}
+
+// RunWithParametersContext executes the command for this fdo app, giving up if the context ends before it starts
+func (data *fdoApplicationData) RunWithParametersContext(ctx context.Context, params, env []string) error {
+	_, err := data.LaunchContext(ctx, params, env)
+	return err
+}
+
+// Launch executes the command for this fdo app in the same way as RunWithParameters.
+// A handle is returned for each instance that started, even if others failed.
+func (data *fdoApplicationData) Launch(params, env []string) ([]*Process, error) {
+	return data.LaunchContext(context.Background(), params, env)
+}
 
-	commands := strings.Split(data.exec, " ")
-	command := commands[0]
-	if command[0] == '"' {
-		command = command[1 : len(command)-1]
+// LaunchContext executes the command for this fdo app in the same way as Launch.
+// The context bounds looking up a terminal emulator and each call to the Launcher.
+// If the context ends no more instances are started and the context error is included in the errors returned.
+func (data *fdoApplicationData) LaunchContext(ctx context.Context, params, env []string) ([]*Process, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 
-	cmd := exec.Command(command)
-	if len(commands) > 1 {
-		cmd.Args = extractArgs(commands, params) // Args[0] should be binary path
+	cmds, err := data.resolve(ctx, params, env)
+	if err != nil {
+		return nil, err
+	}
+
+	var procs []*Process
+	var errs LaunchErrors
+	for _, cmd := range cmds {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+
+		p, err := data.launcher().Launch(ctx, cmd)
+		if err != nil {
+			errs = append(errs, err)
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+		procs = append(procs, p)
 	}
+	return procs, errs.asError()
+}
 
-	cmd.Env = vars
-	return cmd.Start()
+// Resolve returns the commands that RunWithParameters would pass to the Launcher, without starting them.
+// These are exactly what is executed, including the shell that exports GIO_LAUNCHED_DESKTOP_FILE_PID.
+// There is one command for each instance of the app that would be started.
+func (data *fdoApplicationData) Resolve(params, env []string) ([]*Command, error) {
+	return data.resolve(context.Background(), params, env)
+}
+
+func (data *fdoApplicationData) resolve(ctx context.Context, params, env []string) ([]*Command, error) {
+	vars := data.launchEnv(env)
+
+	lines, err := data.entryCommandLines(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := data.launchDir()
+	if err != nil {
+		return nil, err
+	}
+
+	cmds := make([]*Command, len(lines))
+	for i, args := range lines {
+		if args, err = launchArgs(args, vars, dir); err != nil {
+			return nil, err
+		}
+		cmds[i] = &Command{ID: data.id, Args: args, Env: vars, Dir: dir}
+	}
+	return cmds, nil
+}
+
+// launcher returns the Launcher configured for the provider that loaded this app, or the default
+func (data *fdoApplicationData) launcher() Launcher {
+	if data.provider == nil {
+		return ExecLauncher{}
+	}
+
+	return data.provider.opts.commandLauncher()
+}
+
+// launchEnv returns the environment for launching this app. This is the current environment
+// with the desktop file being launched recorded, as GLib does, followed by any variables passed.
+// Any desktop file recorded when the current process was launched is not passed on.
+func (data *fdoApplicationData) launchEnv(env []string) []string {
+	var vars []string
+	for _, item := range os.Environ() {
+		name, _, _ := strings.Cut(item, "=")
+		if name != "GIO_LAUNCHED_DESKTOP_FILE" && name != "GIO_LAUNCHED_DESKTOP_FILE_PID" {
+			vars = append(vars, item)
+		}
+	}
+	if data.path != "" {
+		vars = append(vars, "GIO_LAUNCHED_DESKTOP_FILE="+data.path)
+	}
+
+	return append(vars, env...)
+}
+
+// launchArgs returns the command line that starts an app with the specified environment.
+// If GIO_LAUNCHED_DESKTOP_FILE is set the command is run through a shell that exports
+// GIO_LAUNCHED_DESKTOP_FILE_PID first, as exec keeps the same process this will be the PID of the app.
+// There is no shell to do this on Windows so the command is not changed.
+// As the shell starts even if the program cannot, an error is returned if it is not found first.
+func launchArgs(args, env []string, dir string) ([]string, error) {
+	if runtime.GOOS == "windows" || lookupEnv(env, "GIO_LAUNCHED_DESKTOP_FILE") == "" {
+		return args, nil
+	}
+	if err := lookPathEnv(args[0], env, dir); err != nil {
+		return nil, err
+	}
+
+	return append([]string{"/bin/sh", "-c", fdoLaunchedPIDScript, "sh"}, args...), nil
+}
+
+// lookPathEnv checks that a program can be executed, searching the PATH of the environment passed
+// instead of our own if the name does not contain a slash. Relative paths are found from workDir, if set.
+func lookPathEnv(file string, env []string, workDir string) error {
+	if strings.Contains(file, "/") {
+		if !isExecutable(file, workDir) {
+			return &exec.Error{Name: file, Err: fs.ErrNotExist}
+		}
+		return nil
+	}
+
+	for _, dir := range filepath.SplitList(lookupEnv(env, "PATH")) {
+		if dir == "" {
+			dir = "." // an empty entry means the current directory, as in the shell
+		}
+		if isExecutable(filepath.Join(dir, file), workDir) {
+			return nil
+		}
+	}
+	return &exec.Error{Name: file, Err: exec.ErrNotFound}
+}
+
+// isExecutable returns true if path is a file that can be executed, relative paths are found from workDir if set
+func isExecutable(path, workDir string) bool {
+	if !filepath.IsAbs(path) && workDir != "" {
+		path = filepath.Join(workDir, path)
+	}
+
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir() && info.Mode()&0o111 != 0
+}
+
+// entryCommandLines returns the arguments to execute to run this entry.
+// Applications use their Exec value and links are opened with the default handler for the URL.
+func (data *fdoApplicationData) entryCommandLines(ctx context.Context, params []string) ([][]string, error) {
+	switch data.kind {
+	case TypeApplication:
+		return data.commandLines(ctx, data.exec, params)
+	case TypeLink:
+		if data.url == "" {
+			return nil, errors.New("no URL to open for " + data.name)
+		}
+		return [][]string{{fdoOpenCommand, data.url}}, nil
+	}
+
+	return nil, fmt.Errorf("cannot run %s entry %s", data.kind, data.name)
+}
+
+// commandLines returns the arguments to execute for each instance of an Exec value,
+// expanding field codes with the parameters.
+func (data *fdoApplicationData) commandLines(ctx context.Context, execLine string, params []string) ([][]string, error) {
+	args, err := SplitExec(execLine)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := data.fieldCodes().instances(args, params)
+	for i, line := range lines {
+		if len(line) == 0 {
+			return nil, errors.New("no command to execute for " + data.name)
+		}
+
+		lines[i], err = data.wrapCommand(ctx, line)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return lines, nil
+}
+
+// wrapCommand returns the command line to execute, running it inside a terminal if the app requires it
+func (data *fdoApplicationData) wrapCommand(ctx context.Context, args []string) ([]string, error) {
+	if !data.terminal {
+		return args, nil
+	}
+	if data.provider == nil {
+		return nil, errors.New("no provider to look up a terminal emulator")
+	}
+
+	return data.provider.terminalCommand(ctx, args)
+}
+
+// shownIn checks the OnlyShowIn and NotShowIn keys against the current desktop names.
+// The first desktop listed in either key decides, otherwise it is shown unless OnlyShowIn was set.
+func (data *fdoApplicationData) shownIn(desktops []string) bool {
+	for _, desktop := range desktops {
+		for _, only := range data.onlyShowIn {
+			if only == desktop {
+				return true
+			}
+		}
+		for _, not := range data.notShowIn {
+			if not == desktop {
+				return false
+			}
+		}
+	}
+
+	return len(data.onlyShowIn) == 0
 }
 
 func (data *fdoApplicationData) mainCategory() string {
@@ -168,44 +626,133 @@ func loadIcon(path string) fyne.Resource {
 	return fyne.NewStaticResource(path, data)
 }
 
-// fdoLookupXdgDataDirs returns a string slice of all XDG_DATA_DIRS
-func fdoLookupXdgDataDirs() []string {
-	dataLocation := os.Getenv("XDG_DATA_DIRS")
-	locationLookup := strings.Split(dataLocation, ":")
-	if len(locationLookup) == 0 || (len(locationLookup) == 1 && locationLookup[0] == "") {
-		var fallbackLocations []string
-		homeDir, err := os.UserHomeDir()
-		if err == nil {
-			fallbackLocations = append(fallbackLocations, filepath.Join(homeDir, ".local/share"))
+// fdoCurrentDesktops returns the names of the current desktop environment from XDG_CURRENT_DESKTOP
+func fdoCurrentDesktops() []string {
+	var desktops []string
+	for _, desktop := range strings.Split(os.Getenv("XDG_CURRENT_DESKTOP"), ":") {
+		if desktop != "" {
+			desktops = append(desktops, desktop)
 		}
-		fallbackLocations = append(fallbackLocations, "/usr/local/share")
-		fallbackLocations = append(fallbackLocations, "/usr/share")
-		return fallbackLocations
 	}
-	return locationLookup
+	return desktops
 }
 
-func fdoForEachApplicationFile(f func(data AppData) bool) {
-	locationLookup := fdoLookupXdgDataDirs()
-	for _, dataDir := range locationLookup {
-		testLocation := filepath.Join(dataDir, "applications")
-		files, err := os.ReadDir(testLocation)
-		if err != nil {
+// fdoCurrentLocale returns the locale used for messages, as set by the LC_ALL, LC_MESSAGES or LANG
+// environment variables in that order. The "C" and "POSIX" locales are returned as an empty string.
+func fdoCurrentLocale() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		locale := os.Getenv(name)
+		if locale == "" {
 			continue
 		}
-		for _, file := range files {
-			if strings.HasPrefix(file.Name(), ".") || file.IsDir() || !strings.HasSuffix(file.Name(), ".desktop") {
-				continue
+
+		if locale == "C" || locale == "POSIX" || strings.HasPrefix(locale, "C.") {
+			return ""
+		}
+		return locale
+	}
+
+	return ""
+}
+
+// fdoDesktopFileID returns the desktop file ID for a file within an applications directory.
+// This is the path relative to that directory with separators replaced by "-", for example
+// applications/kde/foo.desktop has the ID kde-foo.desktop.
+func fdoDesktopFileID(appsDir, path string) string {
+	rel, err := filepath.Rel(appsDir, path)
+	if err != nil {
+		return filepath.Base(path)
+	}
+
+	return strings.ReplaceAll(filepath.ToSlash(rel), "/", "-")
+}
+
+// fdoDesktopFileIDForPath returns the desktop file ID for a desktop file within the applications directory
+// of one of the data directories. Files outside of these directories are identified by their file name.
+func fdoDesktopFileIDForPath(path string) string {
+	for _, dataDir := range fdoLookupXdgDataDirs() {
+		appsDir := filepath.Join(dataDir, "applications")
+		rel, err := filepath.Rel(appsDir, path)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return fdoDesktopFileID(appsDir, path)
+		}
+	}
+
+	return filepath.Base(path)
+}
+
+// fdoWalkEntries calls fn with the path of each file ending in suffix within dir and its subdirectories.
+// Symlinked directories are followed but each real directory is only visited once, to avoid loops.
+// If fn returns true the walk stops and true is returned.
+func fdoWalkEntries(dir, suffix string, visited map[string]bool, fn func(path string) bool) bool {
+	resolved, err := filepath.EvalSymlinks(dir)
+	if err != nil || visited[resolved] {
+		return false
+	}
+	visited[resolved] = true
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return false
+	}
+	for _, file := range files {
+		if strings.HasPrefix(file.Name(), ".") {
+			continue
+		}
+
+		path := filepath.Join(dir, file.Name())
+		isDir := file.IsDir()
+		if file.Type()&fs.ModeSymlink != 0 {
+			info, err := os.Stat(path)
+			isDir = err == nil && info.IsDir()
+		}
+		if isDir {
+			if fdoWalkEntries(path, suffix, visited, fn) {
+				return true
 			}
+		} else if strings.HasSuffix(file.Name(), suffix) && fn(path) {
+			return true
+		}
+	}
+	return false
+}
 
-			icon := newFdoIconData(filepath.Join(testLocation, file.Name()))
-			if icon == nil {
-				continue
+// forEachApplicationFile calls fn for each desktop file in the applications data directories, including subdirectories.
+func (f *fdoIconProvider) forEachApplicationFile(fn func(data AppData) bool) {
+	f.forEachEntryFile("applications", ".desktop", fn)
+}
+
+// forEachEntryFile calls fn for each file ending in suffix within the named directory of each data directory.
+// Where files in multiple directories have the same desktop file ID only the first is used,
+// and an entry with Hidden=true is treated as deleted so that ID is skipped completely.
+func (f *fdoIconProvider) forEachEntryFile(subDir, suffix string, fn func(data AppData) bool) {
+	locale := f.locale()
+	seen := make(map[string]bool)
+	locationLookup := fdoLookupXdgDataDirs()
+	for _, dataDir := range locationLookup {
+		testLocation := filepath.Join(dataDir, subDir)
+		done := fdoWalkEntries(testLocation, suffix, make(map[string]bool), func(path string) bool {
+			id := fdoDesktopFileID(testLocation, path)
+			if seen[id] {
+				return false
 			}
 
-			if f(icon) {
-				return
+			icon := newFdoIconData(path, locale)
+			if icon == nil {
+				return false
+			}
+			seen[id] = true
+			app := icon.(*fdoApplicationData)
+			if app.deleted {
+				return false
 			}
+			app.id = id
+			app.provider = f
+
+			return fn(icon)
+		})
+		if done {
+			return
 		}
 	}
 }
@@ -214,7 +761,8 @@ func fdoForEachApplicationFile(f func(data AppData) bool) {
 func (f *fdoIconProvider) lookupApplicationByMetadata(appName string) AppData {
 	var returnIcon AppData
 	f.cache.forEachCachedApplication(func(_ string, icon AppData) bool {
-		if icon.(*fdoApplicationData).name == appName || icon.(*fdoApplicationData).exec == appName {
+		data := icon.(*fdoApplicationData)
+		if data.name == appName || data.baseName == appName || data.exec == appName {
 			returnIcon = icon
 			return true
 		}
@@ -231,7 +779,7 @@ func (f *fdoIconProvider) lookupApplication(appName string) AppData {
 
 	var found AppData
 	f.cache.forEachCachedApplication(func(name string, icon AppData) bool {
-		if strings.EqualFold(name, appName) {
+		if strings.EqualFold(name, appName) || strings.EqualFold(icon.(*fdoApplicationData).baseName, appName) {
 			found = icon
 			return true
 		}
@@ -482,110 +1030,141 @@ func fdoLookupAvailableThemes() []string {
 	return themes
 }
 
-// newFdoIconData creates and returns a struct that contains needed fields from a .desktop file
-func newFdoIconData(desktopPath string) AppData {
-	file, err := os.Open(desktopPath)
+// newFdoIconData creates and returns a struct that contains needed fields from a .desktop file.
+// Translated values are looked up for the specified locale, if one is set.
+func newFdoIconData(desktopPath, locale string) AppData {
+	file, err := LoadDesktopFile(desktopPath)
 	if err != nil {
-		fyne.LogError("Could not open file", err)
+		fyne.LogError("Could not read desktop file", err)
+		return nil
+	}
+	for _, warning := range file.Warnings() {
+		fyne.LogError("Problem in desktop file", warning)
+	}
+	entry := file.Group("Desktop Entry")
+	if entry == nil {
+		fyne.LogError("Missing Desktop Entry group", &DesktopFileError{Path: desktopPath, Msg: "no [Desktop Entry] group"})
 		return nil
 	}
-	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
-	fdoApp := fdoApplicationData{name: "", iconName: "", iconPath: "", exec: ""}
-	var currentSection, currentAction string
-	for scanner.Scan() {
-		line := scanner.Text()
-		if strings.HasPrefix(line, "[") {
-			currentSection = line
+	fdoApp := fdoApplicationData{
+		path:        desktopPath,
+		kind:        AppType(entry.String("Type")),
+		url:         entry.String("URL"),
+		wmClass:     entry.String("StartupWMClass"),
+		name:        entry.LocaleString("Name", locale),
+		baseName:    entry.String("Name"),
+		iconName:    entry.String("Icon"),
+		exec:        entry.String("Exec"),
+		tryExec:     entry.String("TryExec"),
+		workDir:     entry.String("Path"),
+		genericName: entry.LocaleString("GenericName", locale),
+		comment:     entry.LocaleString("Comment", locale),
+		keywords:    entry.LocaleStringList("Keywords", locale),
+		categories:  entry.StringList("Categories"),
+		mime:        entry.StringList("MimeType"),
+		onlyShowIn:  entry.StringList("OnlyShowIn"),
+		notShowIn:   entry.StringList("NotShowIn"),
+	}
+	if fdoApp.kind == "" {
+		fdoApp.kind = TypeApplication
+	}
+	if fdoApp.iconName != "" {
+		if _, err := os.Stat(fdoApp.iconName); err == nil {
+			fdoApp.iconPath = fdoApp.iconName
 		}
-		if currentSection == "[X-Fyne Source]" {
-			if fdoApp.source == nil {
-				fdoApp.source = &AppSource{}
-			}
-			if strings.HasPrefix(line, "Repo=") {
-				name := strings.SplitAfter(line, "=")
-				fdoApp.source.Repo = name[1]
-			}
-			if strings.HasPrefix(line, "Dir=") {
-				name := strings.SplitAfter(line, "=")
-				fdoApp.source.Dir = name[1]
-			}
-		} else if strings.Contains(currentSection, "[Desktop Action") {
-			var action **fdoAction
-			if currentSection == currentAction {
-				act := fdoApp.actions[len(fdoApp.actions)-1].(*fdoAction)
-				action = &act
-			} else if strings.Contains(line, "[Desktop Action") {
-				act := &fdoAction{}
-				action = &act
-				currentAction = line
-				fdoApp.actions = append(fdoApp.actions, *action)
-			}
+	}
+	fdoApp.noDisplay, err = entry.Bool("NoDisplay")
+	if err != nil {
+		fyne.LogError("Could not read NoDisplay", err)
+	}
+	fdoApp.deleted, err = entry.Bool("Hidden")
+	if err != nil {
+		fyne.LogError("Could not read Hidden", err)
+	}
+	fdoApp.terminal, err = entry.Bool("Terminal")
+	if err != nil {
+		fyne.LogError("Could not read Terminal", err)
+	}
+	fdoApp.terminalArgExec = fdoTerminalExecArg(entry, fdoApp.execProgram())
 
-			if strings.HasPrefix(line, "Name=") {
-				name := strings.SplitAfter(line, "=")
-				(*action).name = name[1]
-			}
-			if strings.HasPrefix(line, "Exec=") {
-				name := strings.SplitAfter(line, "=")
-				(*action).exec = name[1]
-			}
-			continue
-		} else if currentSection == "[Desktop Entry]" {
-			// fall through to code below
-		} else {
-			continue
+	for _, group := range file.Groups() {
+		if group.Name() == "X-Fyne Source" {
+			fdoApp.source = &AppSource{Repo: group.String("Repo"), Dir: group.String("Dir")}
+		} else if strings.HasPrefix(group.Name(), "Desktop Action ") {
+			fdoApp.actions = append(fdoApp.actions, &fdoAction{name: group.LocaleString("Name", locale), exec: group.String("Exec"), parent: &fdoApp})
 		}
-		if strings.HasPrefix(line, "Name=") {
-			name := strings.SplitAfter(line, "=")
-			fdoApp.name = name[1]
-		} else if strings.HasPrefix(line, "Icon=") {
-			icon := strings.SplitAfter(line, "=")
-			fdoApp.iconName = icon[1]
-			if _, err := os.Stat(icon[1]); err == nil {
-				fdoApp.iconPath = icon[1]
-			}
-		} else if strings.HasPrefix(line, "Exec=") {
-			exe := strings.SplitAfter(line, "=")
-			fdoApp.exec = exe[1]
-		} else if strings.HasPrefix(line, "Categories=") {
-			cats := strings.SplitAfter(line, "=")
-			fdoApp.categories = strings.Split(cats[1], ";")
-		} else if strings.HasPrefix(line, "MimeType=") {
-			mimes := strings.SplitAfter(line, "=")
-			fdoApp.mime = strings.Split(mimes[1], ";")
-		} else if strings.HasPrefix(line, "NoDisplay=") {
-			val := strings.Split(line, "=")
-			if strings.TrimSpace(val[1]) == "true" {
-				fdoApp.hide = true
-			}
-		}
-	}
-	if err := scanner.Err(); err != nil {
-		fyne.LogError("Could not read file", err)
-		return nil
 	}
 	return &fdoApp
 }
 
 type fdoIconProvider struct {
-	cache *appCache
+	cache    *appCache
+	opts     providerOptions
+	procRoot string
 }
 
-// AvailableApps returns all of the available applications in a AppData slice
+// locale returns the locale to use for translated app information
+func (f *fdoIconProvider) locale() string {
+	if f.opts.locale != "" {
+		return f.opts.locale
+	}
+
+	return fdoCurrentLocale()
+}
+
+// desktops returns the names of the current desktop, used to check if apps should be shown
+func (f *fdoIconProvider) desktops() []string {
+	if f.opts.desktops != nil {
+		return f.opts.desktops
+	}
+
+	return fdoCurrentDesktops()
+}
+
+// AvailableApps returns all of the available applications and links in a AppData slice.
+// Apps that are not shown in the current desktop are skipped, as are apps that are not installed
+// unless the provider was created WithUninstalledApps.
 func (f *fdoIconProvider) AvailableApps() []AppData {
+	desktops := f.desktops()
 	var icons []AppData
-	fdoForEachApplicationFile(func(icon AppData) bool {
+	f.forEachApplicationFile(func(icon AppData) bool {
 		if icon == nil {
 			return false
 		}
+		if icon.Type() != TypeApplication && icon.Type() != TypeLink {
+			return false
+		}
+		if !icon.(*fdoApplicationData).shownIn(desktops) {
+			return false
+		}
+		if !f.opts.showUninstalled && !icon.Installed() {
+			return false
+		}
 		icons = append(icons, icon)
 		return false
 	})
 	return icons
 }
 
+// AvailableDirectories returns the Directory entries that describe menu folders.
+// These are read from the desktop-directories data directories, along with any
+// entries in the applications directories that have Type=Directory.
+func (f *fdoIconProvider) AvailableDirectories() []AppData {
+	desktops := f.desktops()
+	var dirs []AppData
+	collect := func(entry AppData) bool {
+		if entry.Type() == TypeDirectory && entry.(*fdoApplicationData).shownIn(desktops) {
+			dirs = append(dirs, entry)
+		}
+		return false
+	}
+
+	f.forEachEntryFile("desktop-directories", ".directory", collect)
+	f.forEachApplicationFile(collect)
+	return dirs
+}
+
 // AvailableThemes returns all available icon themes in a string slice
 func (f *fdoIconProvider) AvailableThemes() []string {
 	return fdoLookupAvailableThemes()
@@ -595,6 +1174,53 @@ func (f *fdoIconProvider) ClearCache() {
 	f.cache.clearCache()
 }
 
+// FindAppByID returns the app with the specified desktop file ID, the ".desktop" suffix is optional.
+// Apps are found even if they would not be listed, because they are not shown in the current desktop
+// or are not installed, but entries that are deleted or shadowed by another data directory are not.
+func (f *fdoIconProvider) FindAppByID(id string) AppData {
+	if id == "" {
+		return nil
+	}
+	if !strings.HasSuffix(id, ".desktop") {
+		id += ".desktop"
+	}
+
+	var found AppData
+	f.cache.forEachCachedApplication(func(_ string, app AppData) bool {
+		if app.ID() == id {
+			found = app
+			return true
+		}
+		return false
+	})
+	if found != nil {
+		return found
+	}
+
+	if f.cache.appIDs == nil {
+		f.cache.appIDs = f.loadAppIDs()
+	}
+	return f.cache.appIDs[id]
+}
+
+// loadAppIDs returns every application and link by its desktop file ID, whether or not it would be listed.
+// Apps that are listed are the same as those in the cache so that an app is always returned as the same value.
+func (f *fdoIconProvider) loadAppIDs() map[string]AppData {
+	apps := make(map[string]AppData)
+	f.cache.forEachCachedApplication(func(_ string, app AppData) bool {
+		apps[app.ID()] = app
+		return false
+	})
+
+	f.forEachApplicationFile(func(app AppData) bool {
+		if _, ok := apps[app.ID()]; !ok && (app.Type() == TypeApplication || app.Type() == TypeLink) {
+			apps[app.ID()] = app
+		}
+		return false
+	})
+	return apps
+}
+
 // FindAppFromName matches an icon name to a location and returns an AppData interface
 func (f *fdoIconProvider) FindAppFromName(appName string) AppData {
 	return f.lookupApplication(appName)
@@ -620,6 +1246,7 @@ func (f *fdoIconProvider) FindAppsMatching(appName string) []AppData {
 
 type fdoAction struct {
 	name, exec string
+	parent     *fdoApplicationData
 }
 
 func (f *fdoAction) Name() string {
@@ -627,22 +1254,54 @@ func (f *fdoAction) Name() string {
 }
 
 func (f *fdoAction) Run(env []string) error {
-	vars := os.Environ()
-	vars = append(vars, env...)
+	return f.RunContext(context.Background(), env)
+}
+
+// RunContext executes the command for this action, giving up if the context ends before it starts
+func (f *fdoAction) RunContext(ctx context.Context, env []string) error {
+	_, err := f.LaunchContext(ctx, env)
+	return err
+}
+
+// Launch executes the command for this action, returning a handle to the process started
+func (f *fdoAction) Launch(env []string) (*Process, error) {
+	return f.LaunchContext(context.Background(), env)
+}
 
-	commands := strings.Split(f.exec, " ")
-	command := commands[0]
-	if command[0] == '"' {
-		command = command[1 : len(command)-1]
+// LaunchContext executes the command for this action, giving up if the context ends before it starts
+func (f *fdoAction) LaunchContext(ctx context.Context, env []string) (*Process, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 
-	cmd := exec.Command(command)
-	if len(commands) > 1 {
-		cmd.Args = commands
+	cmd, err := f.resolve(ctx, env)
+	if err != nil {
+		return nil, err
 	}
+	return f.parent.launcher().Launch(ctx, cmd)
+}
 
-	cmd.Env = vars
-	return cmd.Start()
+// Resolve returns the command that Run would pass to the Launcher, without starting it
+func (f *fdoAction) Resolve(env []string) (*Command, error) {
+	return f.resolve(context.Background(), env)
+}
+
+func (f *fdoAction) resolve(ctx context.Context, env []string) (*Command, error) {
+	vars := f.parent.launchEnv(env)
+
+	lines, err := f.parent.commandLines(ctx, f.exec, nil)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := f.parent.launchDir()
+	if err != nil {
+		return nil, err
+	}
+	args, err := launchArgs(lines[0], vars, dir)
+	if err != nil {
+		return nil, err
+	}
+	return &Command{ID: f.parent.id, Args: args, Env: vars, Dir: dir}, nil
 }
 
 func findOneAppFromNames(f Provider, names ...string) AppData {
@@ -667,7 +1326,7 @@ func appendAppIfExists(apps []AppData, app AppData) []AppData {
 func (f *fdoIconProvider) DefaultApps() []AppData {
 	var apps []AppData
 
-	apps = appendAppIfExists(apps, findOneAppFromNames(f, "fyneterm", "xfce4-terminal", "gnome-terminal", "org.kde.konsole", "xterm"))
+	apps = appendAppIfExists(apps, findOneAppFromNames(f, fdoTerminalNames...))
 	apps = appendAppIfExists(apps, findOneAppFromNames(f, "chromium", "google-chrome", "firefox"))
 	apps = appendAppIfExists(apps, findOneAppFromNames(f, "sylpheed", "thunderbird", "evolution"))
 	apps = appendAppIfExists(apps, f.FindAppFromName("gimp"))
@@ -691,9 +1350,10 @@ func (f *fdoIconProvider) CategorizedApps() map[string][]AppData {
 	return cats
 }
 
-// NewFDOProvider returns a new application provider following the FreeDesktop.org specifications
-func NewFDOProvider() Provider {
-	source := &fdoIconProvider{}
+// NewFDOProvider returns a new application provider following the FreeDesktop.org specifications.
+// Any options passed will configure how apps are looked up.
+func NewFDOProvider(opts ...Option) Provider {
+	source := &fdoIconProvider{opts: newProviderOptions(opts), procRoot: fdoProcRoot}
 	source.cache = newAppCache(source)
 	return source
 }
@@ -1,9 +1,12 @@
 package appie
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -20,17 +23,24 @@ func exists(data AppData) bool {
 	return data != nil && data.Icon(iconTheme, iconSize) != nil
 }
 
+// setTestPath sets PATH to a directory containing empty executables with the names passed
+func setTestPath(t *testing.T, names ...string) {
+	dir := t.TempDir()
+	for _, name := range names {
+		assert.Nil(t, os.WriteFile(filepath.Join(dir, name), nil, 0o755))
+	}
+	t.Setenv("PATH", dir)
+}
+
 func setTestEnv(t *testing.T) {
 	workingDir, err := os.Getwd()
 	if err != nil {
 		fyne.LogError("Could not get current working directory", err)
 		t.FailNow()
 	}
-	err = os.Setenv("XDG_DATA_DIRS", filepath.Join(workingDir, "testdata"))
-	if err != nil {
-		fyne.LogError("Could not set test environment variable", err)
-		t.FailNow()
-	}
+	t.Setenv("XDG_DATA_DIRS", filepath.Join(workingDir, "testdata"))
+	// avoid picking up the user's own apps, the duplicate directory will be ignored
+	t.Setenv("XDG_DATA_HOME", filepath.Join(workingDir, "testdata"))
 }
 
 // applications/app1.desktop and icons/default_theme/apps/32x32/app1.png
@@ -77,6 +87,81 @@ func TestFdoIconHide(t *testing.T) {
 	setTestEnv(t)
 	data := NewFDOProvider().(*fdoIconProvider).lookupApplication("app4")
 	assert.Equal(t, true, data.Hidden())
+	assert.Equal(t, true, data.NoDisplay())
+}
+
+// local/applications/app8.desktop is Hidden so deletes applications/app8.desktop
+func TestFdoIconDeleted(t *testing.T) {
+	setTestEnv(t)
+	workingDir, _ := os.Getwd()
+	t.Setenv("XDG_DATA_HOME", filepath.Join(workingDir, "testdata", "local"))
+	provider := NewFDOProvider()
+	assert.Nil(t, provider.FindAppFromName("App8"))
+	assert.NotNil(t, provider.FindAppFromName("App7"))
+	for _, app := range provider.AvailableApps() {
+		assert.False(t, app.Hidden() && !app.NoDisplay())
+	}
+}
+
+// local/applications/app6.desktop overrides applications/app6.desktop
+func TestFdoIconProvider_FindAppByID(t *testing.T) {
+	setTestEnv(t)
+	workingDir, _ := os.Getwd()
+	t.Setenv("XDG_DATA_HOME", filepath.Join(workingDir, "testdata", "local"))
+	provider := NewFDOProvider()
+
+	data := provider.FindAppByID("app6.desktop")
+	assert.NotNil(t, data)
+	assert.Equal(t, "app6.desktop", data.ID())
+	assert.Equal(t, "Local override", data.Comment())
+	assert.Equal(t, data, provider.FindAppByID("app6"))
+	assert.Nil(t, provider.FindAppByID("app8.desktop"))
+
+	count := 0
+	for _, app := range provider.AvailableApps() {
+		if app.ID() == "app6.desktop" {
+			count++
+		}
+	}
+	assert.Equal(t, 1, count)
+}
+
+// applications/kde-only.desktop and applications/missing.desktop
+func TestFdoIconProvider_FindAppByIDNotListed(t *testing.T) {
+	setTestEnv(t)
+	provider := NewFDOProvider(WithCurrentDesktop("GNOME"))
+	assert.Nil(t, provider.FindAppFromName("KDE Only"))
+
+	data := provider.FindAppByID("kde-only.desktop")
+	assert.NotNil(t, data)
+	assert.Equal(t, "KDE Only", data.Name())
+
+	assert.Same(t, data, provider.FindAppByID("kde-only.desktop"))
+
+	data = provider.FindAppByID("missing")
+	assert.NotNil(t, data)
+	assert.False(t, data.Installed())
+	assert.Nil(t, provider.FindAppByID("not-a-file.desktop"))
+
+	// the apps that are not listed are loaded once, until the cache is cleared
+	assert.NotNil(t, provider.(*fdoIconProvider).cache.appIDs)
+	provider.ClearCache()
+	assert.Nil(t, provider.(*fdoIconProvider).cache.appIDs)
+}
+
+func TestFdoDesktopFileID(t *testing.T) {
+	apps := filepath.Join("usr", "share", "applications")
+	assert.Equal(t, "foo.desktop", fdoDesktopFileID(apps, filepath.Join(apps, "foo.desktop")))
+	assert.Equal(t, "kde-foo.desktop", fdoDesktopFileID(apps, filepath.Join(apps, "kde", "foo.desktop")))
+}
+
+func TestFdoDesktopFileIDForPath(t *testing.T) {
+	setTestEnv(t)
+	workingDir, _ := os.Getwd()
+	apps := filepath.Join(workingDir, "testdata", "applications")
+
+	assert.Equal(t, "kde-foo.desktop", fdoDesktopFileIDForPath(filepath.Join(apps, "kde", "foo.desktop")))
+	assert.Equal(t, "foo.desktop", fdoDesktopFileIDForPath(filepath.Join(workingDir, "other", "kde", "foo.desktop")))
 }
 
 // applications/app4.desktop and pixmaps/app4.png
@@ -160,3 +245,280 @@ func TestFdoExtractArgs(t *testing.T) {
 
 	assert.Equal(t, []string{"-u", "thing", "https://example.com"}, extracted)
 }
+
+func TestFdoCurrentLocale(t *testing.T) {
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_MESSAGES", "de_DE.UTF-8")
+	t.Setenv("LANG", "en_GB.UTF-8")
+	assert.Equal(t, "de_DE.UTF-8", fdoCurrentLocale())
+
+	t.Setenv("LC_ALL", "C")
+	assert.Equal(t, "", fdoCurrentLocale())
+}
+
+func TestFdoLocalizedName(t *testing.T) {
+	setTestEnv(t)
+	data := NewFDOProvider(WithLocale("de_DE.UTF-8")).(*fdoIconProvider).lookupApplication("app1")
+	assert.Equal(t, "Anwendung Eins", data.Name())
+	assert.Equal(t, 1, len(data.Actions()))
+	assert.Equal(t, "Neues Fenster", data.Actions()[0].Name())
+
+	data = NewFDOProvider(WithLocale("fr_FR")).(*fdoIconProvider).lookupApplication("app1")
+	assert.Equal(t, "App1", data.Name())
+	assert.Equal(t, "New Window", data.Actions()[0].Name())
+}
+
+func TestFdoIconProvider_FindAppFromNameTranslated(t *testing.T) {
+	setTestEnv(t)
+	provider := NewFDOProvider(WithLocale("de_DE"))
+
+	// apps can be found by their untranslated name as well as the name shown for the locale
+	assert.Equal(t, "Anwendung Eins", provider.FindAppFromName("App1").Name())
+	assert.Equal(t, "Anwendung Eins", provider.FindAppFromName("Anwendung Eins").Name())
+	assert.Equal(t, "Anwendung Eins", provider.(*fdoIconProvider).lookupApplicationByMetadata("App1").Name())
+}
+
+// applications/app5.desktop
+func TestFdoIconDescription(t *testing.T) {
+	setTestEnv(t)
+	data := NewFDOProvider(WithLocale("de")).(*fdoIconProvider).lookupApplication("app5")
+	assert.Equal(t, "Testanwendung", data.GenericName())
+	assert.Equal(t, "An app for testing", data.Comment())
+	assert.Equal(t, []string{"test", "example"}, data.Keywords())
+}
+
+// applications/missing.desktop
+func TestFdoIconProvider_Uninstalled(t *testing.T) {
+	setTestEnv(t)
+	assert.Nil(t, NewFDOProvider().FindAppFromName("Missing"))
+	for _, app := range NewFDOProvider().AvailableApps() {
+		assert.True(t, app.Installed())
+	}
+
+	data := NewFDOProvider(WithUninstalledApps()).FindAppFromName("Missing")
+	assert.NotNil(t, data)
+	assert.False(t, data.Installed())
+}
+
+// applications/gnome-settings.desktop and applications/kde-hidden.desktop
+func TestFdoIconProvider_CurrentDesktop(t *testing.T) {
+	setTestEnv(t)
+	t.Setenv("XDG_CURRENT_DESKTOP", "KDE")
+	provider := NewFDOProvider()
+	assert.Nil(t, provider.FindAppFromName("GNOME Settings"))
+	assert.Nil(t, provider.FindAppFromName("Not For KDE"))
+
+	provider = NewFDOProvider(WithCurrentDesktop("ubuntu", "GNOME"))
+	assert.NotNil(t, provider.FindAppFromName("GNOME Settings"))
+	assert.NotNil(t, provider.FindAppFromName("Not For KDE"))
+
+	provider = NewFDOProvider(WithCurrentDesktop())
+	assert.Nil(t, provider.FindAppFromName("GNOME Settings"))
+	assert.NotNil(t, provider.FindAppFromName("Not For KDE"))
+}
+
+func TestFdoApplicationData_shownIn(t *testing.T) {
+	data := &fdoApplicationData{onlyShowIn: []string{"GNOME"}, notShowIn: []string{"XFCE"}}
+	assert.True(t, data.shownIn([]string{"ubuntu", "GNOME"}))
+	assert.False(t, data.shownIn([]string{"XFCE", "GNOME"}))
+	assert.False(t, data.shownIn(nil))
+}
+
+func TestFdoFieldCodes(t *testing.T) {
+	codes := &fdoFieldCodes{icon: "app-icon", name: "My App", location: "/usr/share/applications/app.desktop"}
+	args := []string{"app", "%i", "--caption", "%c", "--desktop=%k", "%d", "%D", "%n", "%N", "%v", "%m", "100%%", "%F"}
+	params := []string{"file:///home/user/My%20File.txt", "/tmp/other.txt", "https://example.com/remote.txt"}
+
+	extracted := codes.expand(args, params)
+	assert.Equal(t, []string{
+		"app", "--icon", "app-icon", "--caption", "My App", "--desktop=/usr/share/applications/app.desktop", "100%",
+		filepath.FromSlash("/home/user/My File.txt"), "/tmp/other.txt", "https://example.com/remote.txt",
+	}, extracted)
+
+	codes = &fdoFieldCodes{}
+	extracted = codes.expand([]string{"app", "%i", "%c", "--open=%u", "%U"}, []string{"file:///one", "https://two"})
+	assert.Equal(t, []string{"app", "--open=file:///one", "https://two"}, extracted)
+}
+
+func TestFdoFieldCodes_instances(t *testing.T) {
+	codes := &fdoFieldCodes{}
+	files := []string{"file:///tmp/one.txt", "file:///tmp/two.txt", "file:///tmp/three.txt"}
+
+	lines := codes.instances([]string{"editor", "%f"}, files)
+	assert.Equal(t, 3, len(lines))
+	for i, line := range lines {
+		assert.Equal(t, []string{"editor", fdoLocalPath(files[i])}, line)
+	}
+
+	lines = codes.instances([]string{"editor", "--files", "%F"}, files)
+	assert.Equal(t, 1, len(lines))
+	assert.Equal(t, 5, len(lines[0]))
+
+	lines = codes.instances([]string{"editor"}, files)
+	assert.Equal(t, [][]string{{"editor"}}, lines)
+}
+
+func TestFdoFileURI(t *testing.T) {
+	path, _ := filepath.Abs(filepath.Join("testdata", "My File.txt"))
+	uri := fdoFileURI(path)
+	assert.True(t, strings.HasPrefix(uri, "file:///"))
+	assert.Equal(t, path, fdoLocalPath(uri))
+
+	assert.Equal(t, "https://example.com", fdoFileURI("https://example.com"))
+}
+
+// applications/wine/Programs/game.desktop
+func TestFdoIconProvider_Subdirectories(t *testing.T) {
+	setTestEnv(t)
+	data := NewFDOProvider().FindAppByID("wine-Programs-game.desktop")
+	assert.NotNil(t, data)
+	assert.Equal(t, "Wine Game", data.Name())
+}
+
+func TestFdoWalkApplications_SymlinkLoop(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "app.desktop"), []byte("[Desktop Entry]\nName=App\n"), 0o644)
+	assert.Nil(t, err)
+	if err := os.Symlink(dir, filepath.Join(dir, "loop")); err != nil {
+		t.Skip("Symlinks not supported", err)
+	}
+
+	var found []string
+	fdoWalkEntries(dir, ".desktop", make(map[string]bool), func(path string) bool {
+		found = append(found, fdoDesktopFileID(dir, path))
+		return false
+	})
+	assert.Equal(t, []string{"app.desktop"}, found)
+}
+
+// applications/fyne-website.desktop
+func TestFdoIconProvider_Link(t *testing.T) {
+	setTestEnv(t)
+	data := NewFDOProvider().FindAppByID("fyne-website.desktop")
+	assert.NotNil(t, data)
+	assert.Equal(t, TypeLink, data.Type())
+
+	lines, err := data.(*fdoApplicationData).entryCommandLines(context.Background(), []string{"ignored"})
+	assert.Nil(t, err)
+	assert.Equal(t, [][]string{{"xdg-open", "https://fyne.io"}}, lines)
+}
+
+// applications/games.desktop and desktop-directories/utility.directory
+func TestFdoIconProvider_AvailableDirectories(t *testing.T) {
+	setTestEnv(t)
+	provider := NewFDOProvider()
+	assert.Nil(t, provider.FindAppByID("games.desktop"))
+
+	dirs := provider.AvailableDirectories()
+	assert.Equal(t, 2, len(dirs))
+	assert.Equal(t, "utility.directory", dirs[0].ID())
+	assert.Equal(t, "Accessories", dirs[0].Name())
+	assert.Equal(t, "games.desktop", dirs[1].ID())
+	for _, dir := range dirs {
+		assert.Equal(t, TypeDirectory, dir.Type())
+		assert.NotNil(t, dir.Run(nil))
+	}
+}
+
+func TestFdoApplicationData_launchEnv(t *testing.T) {
+	data := &fdoApplicationData{path: "/usr/share/applications/app.desktop"}
+	env := data.launchEnv([]string{"DISPLAY=:1"})
+
+	assert.Equal(t, "GIO_LAUNCHED_DESKTOP_FILE=/usr/share/applications/app.desktop", env[len(env)-2])
+	assert.Equal(t, "DISPLAY=:1", env[len(env)-1])
+}
+
+func TestFdoApplicationData_launchEnv_Inherited(t *testing.T) {
+	t.Setenv("GIO_LAUNCHED_DESKTOP_FILE", "/usr/share/applications/launcher.desktop")
+	t.Setenv("GIO_LAUNCHED_DESKTOP_FILE_PID", "42")
+
+	env := (&fdoApplicationData{}).launchEnv(nil)
+	assert.Equal(t, "", lookupEnv(env, "GIO_LAUNCHED_DESKTOP_FILE"))
+	assert.Equal(t, "", lookupEnv(env, "GIO_LAUNCHED_DESKTOP_FILE_PID"))
+
+	env = (&fdoApplicationData{path: "/usr/share/applications/app.desktop"}).launchEnv(nil)
+	assert.Equal(t, "/usr/share/applications/app.desktop", lookupEnv(env, "GIO_LAUNCHED_DESKTOP_FILE"))
+	assert.Equal(t, "", lookupEnv(env, "GIO_LAUNCHED_DESKTOP_FILE_PID"))
+}
+
+func TestLaunchArgs_LaunchedPID(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Shell not available")
+	}
+	out := filepath.Join(t.TempDir(), "env")
+	env := []string{"GIO_LAUNCHED_DESKTOP_FILE=/tmp/app.desktop"}
+	args := []string{"/bin/sh", "-c", `echo "$GIO_LAUNCHED_DESKTOP_FILE $GIO_LAUNCHED_DESKTOP_FILE_PID" > "$0"`, out}
+	wrapped, err := launchArgs(args, nil, "")
+	assert.Nil(t, err)
+	assert.Equal(t, args, wrapped)
+
+	wrapped, err = launchArgs(args, env, "")
+	assert.Nil(t, err)
+	p, err := ExecLauncher{}.Launch(context.Background(), &Command{Args: wrapped, Env: env})
+	assert.Nil(t, err)
+	assert.Nil(t, p.Wait())
+
+	content, err := os.ReadFile(out)
+	assert.Nil(t, err)
+	assert.Equal(t, fmt.Sprintf("/tmp/app.desktop %d\n", p.PID()), string(content))
+}
+
+// launchedArgs returns the command line expected when launching from a desktop file on the current OS
+func launchedArgs(args ...string) []string {
+	if runtime.GOOS == "windows" {
+		return args
+	}
+	return append([]string{"/bin/sh", "-c", fdoLaunchedPIDScript, "sh"}, args...)
+}
+
+func TestFdoApplicationData_Resolve(t *testing.T) {
+	setTestEnv(t)
+	setTestPath(t, "app1")
+	app := NewFDOProvider().FindAppByID("app1")
+
+	cmds, err := app.Resolve([]string{"file.txt"}, []string{"FOO=bar"})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(cmds))
+	assert.Equal(t, "app1.desktop", cmds[0].ID)
+	assert.Equal(t, launchedArgs("app1"), cmds[0].Args)
+	assert.Equal(t, "FOO=bar", cmds[0].Env[len(cmds[0].Env)-1])
+	assert.Equal(t, "GIO_LAUNCHED_DESKTOP_FILE="+app.(*fdoApplicationData).path, cmds[0].Env[len(cmds[0].Env)-2])
+
+	cmd, err := app.Actions()[0].Resolve(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, launchedArgs("app1", "--new-window"), cmd.Args)
+
+	data := &fdoApplicationData{id: "viewer.desktop", exec: "viewer %f", kind: TypeApplication}
+	cmds, err = data.Resolve([]string{"a.png", "b.png"}, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(cmds))
+	assert.Equal(t, []string{"viewer", "a.png"}, cmds[0].Args)
+	assert.Equal(t, []string{"viewer", "b.png"}, cmds[1].Args)
+}
+
+// applications/wine/Programs/game.desktop
+func TestFdoApplicationData_WorkingDir(t *testing.T) {
+	setTestEnv(t)
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	setTestPath(t, "env", "setup")
+	data := NewFDOProvider().FindAppByID("wine-Programs-game.desktop")
+	dir := filepath.Join(home, ".wine", "drive_c", "Games")
+	assert.Equal(t, dir, data.WorkingDir())
+
+	_, err := data.Resolve(nil, nil)
+	assert.NotNil(t, err)
+
+	assert.Nil(t, os.MkdirAll(dir, 0o755))
+	cmds, err := data.Resolve(nil, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, dir, cmds[0].Dir)
+
+	action := &fdoAction{name: "Setup", exec: "setup", parent: data.(*fdoApplicationData)}
+	cmd, err := action.Resolve(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, dir, cmd.Dir)
+
+	assert.Equal(t, "", NewFDOProvider().FindAppByID("app1").WorkingDir())
+}
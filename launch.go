@@ -0,0 +1,108 @@
+package appie
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"syscall"
+)
+
+// Process is a handle to an app that has been launched.
+// The process is always waited on once started, so it will not be left as a zombie when it exits.
+type Process struct {
+	pid    int
+	done   chan struct{}
+	err    error
+	code   int
+	signal func(os.Signal) error
+}
+
+// startProcess starts the command and returns a handle that will reap it when it exits
+func startProcess(cmd *exec.Cmd) (*Process, error) {
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	p := &Process{pid: cmd.Process.Pid, done: make(chan struct{}), code: -1, signal: cmd.Process.Signal}
+	go func() {
+		p.err = cmd.Wait()
+		if cmd.ProcessState != nil {
+			p.code = cmd.ProcessState.ExitCode()
+		}
+		close(p.done)
+	}()
+	return p, nil
+}
+
+// PID returns the process ID of the launched app
+func (p *Process) PID() int {
+	return p.pid
+}
+
+// Done returns a channel that is closed once the process has exited
+func (p *Process) Done() <-chan struct{} {
+	return p.done
+}
+
+// Wait blocks until the process exits and returns the error, if any, that it exited with.
+// A process that exits with a non-zero status will return an *exec.ExitError.
+func (p *Process) Wait() error {
+	<-p.done
+	return p.err
+}
+
+// ExitCode returns the exit status of the process, or -1 if it is still running or was terminated by a signal
+func (p *Process) ExitCode() int {
+	select {
+	case <-p.done:
+		return p.code
+	default:
+		return -1
+	}
+}
+
+// Signal sends a signal to the process
+func (p *Process) Signal(sig os.Signal) error {
+	return p.signal(sig)
+}
+
+// Terminate asks the process to exit, on Windows the process is killed as it cannot be signalled
+func (p *Process) Terminate() error {
+	if runtime.GOOS == "windows" {
+		return p.Kill()
+	}
+
+	return p.signal(syscall.SIGTERM)
+}
+
+// Kill causes the process to exit immediately
+func (p *Process) Kill() error {
+	return p.signal(os.Kill)
+}
+
+// LaunchErrors is returned when one or more instances of an app could not be started.
+// Each error reports the failure of one instance.
+type LaunchErrors []error
+
+func (e LaunchErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the errors of each instance that failed to start
+func (e LaunchErrors) Unwrap() []error {
+	return e
+}
+
+// asError returns nil if no errors were recorded, so that an empty list is not returned as an error
+func (e LaunchErrors) asError() error {
+	if len(e) == 0 {
+		return nil
+	}
+
+	return e
+}
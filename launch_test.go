@@ -0,0 +1,130 @@
+package appie
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStartProcess_ExitCode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Shell not available")
+	}
+
+	p, err := startProcess(exec.Command("/bin/sh", "-c", "exit 3"))
+	assert.Nil(t, err)
+	assert.NotZero(t, p.PID())
+
+	var exitErr *exec.ExitError
+	assert.True(t, errors.As(p.Wait(), &exitErr))
+	assert.Equal(t, 3, p.ExitCode())
+	select {
+	case <-p.Done():
+	default:
+		t.Error("Done should be closed once the process has exited")
+	}
+}
+
+func TestStartProcess_Terminate(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Shell not available")
+	}
+
+	p, err := startProcess(exec.Command("/bin/sh", "-c", "sleep 10"))
+	assert.Nil(t, err)
+	assert.Equal(t, -1, p.ExitCode())
+
+	assert.Nil(t, p.Terminate())
+	assert.NotNil(t, p.Wait())
+	assert.Equal(t, -1, p.ExitCode())
+	assert.Equal(t, os.ErrProcessDone, p.Kill())
+}
+
+func TestStartProcess_Missing(t *testing.T) {
+	p, err := startProcess(exec.Command(filepath.Join(t.TempDir(), "missing")))
+	assert.NotNil(t, err)
+	assert.Nil(t, p)
+}
+
+func TestFdoApplicationData_Launch(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Shell not available")
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "launch.desktop")
+	err := os.WriteFile(path, []byte("[Desktop Entry]\nType=Application\nName=Launch\nExec=/bin/sh -c \"exit 0\" %f\n"), 0o644)
+	assert.Nil(t, err)
+	data := newFdoIconData(path, "")
+
+	procs, err := data.Launch([]string{"a", "b"}, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(procs))
+	for _, p := range procs {
+		assert.Nil(t, p.Wait())
+		assert.Equal(t, 0, p.ExitCode())
+	}
+}
+
+func TestFdoApplicationData_Launch_Missing(t *testing.T) {
+	setTestPath(t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "missing.desktop")
+	err := os.WriteFile(path, []byte("[Desktop Entry]\nType=Application\nName=Missing\nExec=/nonexistent/bin %f\n"+
+		"Actions=search;\n\n[Desktop Action search]\nName=Search\nExec=appie-missing-binary --search\n"), 0o644)
+	assert.Nil(t, err)
+	data := newFdoIconData(path, "")
+
+	// the program is checked before starting the shell that would run it
+	assert.NotNil(t, data.Run(nil))
+	procs, err := data.Launch([]string{"a", "b"}, nil)
+	assert.NotNil(t, err)
+	assert.Nil(t, procs)
+	_, err = data.Actions()[0].Launch(nil)
+	assert.NotNil(t, err)
+}
+
+func TestFdoApplicationData_LaunchContext(t *testing.T) {
+	data := &fdoApplicationData{name: "App", exec: "app %f", kind: TypeApplication}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	procs, err := data.LaunchContext(ctx, []string{"a", "b"}, nil)
+	assert.Equal(t, context.Canceled, err)
+	assert.Nil(t, procs)
+
+	action := &fdoAction{name: "New", exec: "app --new", parent: data}
+	p, err := action.LaunchContext(ctx, nil)
+	assert.Equal(t, context.Canceled, err)
+	assert.Nil(t, p)
+}
+
+func TestFdoApplicationData_LaunchContext_Blocked(t *testing.T) {
+	setTestEnv(t)
+	launched := 0
+	provider := NewFDOProvider(WithLauncher(LauncherFunc(func(ctx context.Context, _ *Command) (*Process, error) {
+		launched++
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})))
+	data := &fdoApplicationData{name: "App", exec: "app %f", kind: TypeApplication, provider: provider.(*fdoIconProvider)}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	procs, err := data.LaunchContext(ctx, []string{"a", "b"}, nil)
+	assert.Equal(t, LaunchErrors{context.DeadlineExceeded}, err)
+	assert.Nil(t, procs)
+	assert.Equal(t, 1, launched)
+}
+
+func TestLaunchErrors(t *testing.T) {
+	errs := LaunchErrors{errors.New("first"), errors.New("second")}
+	assert.Equal(t, "first; second", errs.Error())
+	assert.Nil(t, LaunchErrors(nil).asError())
+}
@@ -0,0 +1,106 @@
+package appie
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os/exec"
+	"strings"
+)
+
+// execSafeChars are the characters that do not need to be quoted in a command line
+const execSafeChars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_@%+=:,./-"
+
+// Command is a fully resolved command line for an app that a Launcher should start.
+type Command struct {
+	ID   string   // ID is the identifier of the app being launched, such as its desktop file ID
+	Args []string // Args is the program to run followed by its arguments
+	Env  []string // Env is the complete environment of the process, if nil the current environment is used
+	Dir  string   // Dir is the working directory of the process, if empty the current directory is used
+
+	Stdout io.Writer // Stdout receives the standard output of the process, if nil it is discarded
+	Stderr io.Writer // Stderr receives the standard error of the process, if nil it is discarded
+}
+
+// String returns the command line quoted in the same way as the Exec key of a desktop file,
+// so that it can be copied to a shell or split again with SplitExec.
+func (c *Command) String() string {
+	args := make([]string, len(c.Args))
+	for i, arg := range c.Args {
+		args[i] = quoteExecArg(arg)
+	}
+	return strings.Join(args, " ")
+}
+
+// quoteExecArg returns an argument surrounded by double quotes, with reserved characters escaped, if it needs quoting
+func quoteExecArg(arg string) string {
+	if arg != "" && strings.Trim(arg, execSafeChars) == "" {
+		return arg
+	}
+
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range arg {
+		if strings.ContainsRune("\"`$\\", r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// Launcher starts the processes for apps. A Launcher can wrap another to change how commands are run,
+// for example to run apps under a sandbox or inside a container.
+type Launcher interface {
+	Launch(ctx context.Context, cmd *Command) (*Process, error)
+}
+
+// LauncherFunc allows a function to be used as a Launcher.
+type LauncherFunc func(ctx context.Context, cmd *Command) (*Process, error)
+
+// Launch calls the function to start the command
+func (f LauncherFunc) Launch(ctx context.Context, cmd *Command) (*Process, error) {
+	return f(ctx, cmd)
+}
+
+// ExecLauncher starts commands as child processes of the current process, it is the default Launcher.
+// The standard input and output of apps are connected to the null device, not those of the launcher,
+// unless the Command specifies where output should be written.
+type ExecLauncher struct {
+	// Detach starts apps in a new session, or process group on Windows, so that they do not receive signals
+	// such as SIGINT and SIGHUP sent to the launcher. Apps are reparented by the system when the launcher exits.
+	Detach bool
+}
+
+// Launch starts the command, unless the context has already ended
+func (l ExecLauncher) Launch(ctx context.Context, cmd *Command) (*Process, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if len(cmd.Args) == 0 {
+		return nil, errors.New("no command to launch")
+	}
+
+	c := exec.Command(cmd.Args[0], cmd.Args[1:]...)
+	c.Env, c.Dir = cmd.Env, cmd.Dir
+	c.Stdout, c.Stderr = cmd.Stdout, cmd.Stderr
+	if l.Detach {
+		detachCmd(c)
+	}
+	return startProcess(c)
+}
+
+// PrefixLauncher returns a Launcher that runs commands through a wrapper program, such as "nice" or "firejail",
+// before passing them to the next Launcher. If next is nil then an ExecLauncher is used.
+func PrefixLauncher(next Launcher, prefix ...string) Launcher {
+	if next == nil {
+		next = ExecLauncher{}
+	}
+
+	return LauncherFunc(func(ctx context.Context, cmd *Command) (*Process, error) {
+		wrapped := *cmd
+		wrapped.Args = append(append([]string{}, prefix...), cmd.Args...)
+		return next.Launch(ctx, &wrapped)
+	})
+}
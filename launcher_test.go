@@ -0,0 +1,112 @@
+package appie
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeLauncher records the commands it is asked to launch instead of starting them
+type fakeLauncher struct {
+	commands []*Command
+	err      error
+}
+
+func (f *fakeLauncher) Launch(_ context.Context, cmd *Command) (*Process, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	f.commands = append(f.commands, cmd)
+	return newFakeProcess(1000 + len(f.commands)), nil
+}
+
+// newFakeProcess returns a handle for a process that has already exited successfully
+func newFakeProcess(pid int) *Process {
+	done := make(chan struct{})
+	close(done)
+	return &Process{pid: pid, done: done, signal: func(os.Signal) error {
+		return os.ErrProcessDone
+	}}
+}
+
+func TestFdoIconProvider_WithLauncher(t *testing.T) {
+	setTestEnv(t)
+	setTestPath(t, "app1")
+	launcher := &fakeLauncher{}
+	provider := NewFDOProvider(WithLauncher(launcher))
+
+	app := provider.FindAppByID("app1")
+	procs, err := app.Launch(nil, []string{"FOO=bar"})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(procs))
+	assert.Equal(t, 1001, procs[0].PID())
+
+	assert.Nil(t, app.Actions()[0].Run(nil))
+	assert.Equal(t, 2, len(launcher.commands))
+	assert.Equal(t, "app1.desktop", launcher.commands[0].ID)
+	assert.Equal(t, launchedArgs("app1"), launcher.commands[0].Args)
+	assert.Equal(t, "FOO=bar", launcher.commands[0].Env[len(launcher.commands[0].Env)-1])
+	assert.Equal(t, launchedArgs("app1", "--new-window"), launcher.commands[1].Args)
+
+	launcher.err = errors.New("failed")
+	assert.Equal(t, LaunchErrors{launcher.err}, app.Run(nil))
+}
+
+func TestPrefixLauncher(t *testing.T) {
+	launcher := &fakeLauncher{}
+	cmd := &Command{ID: "app.desktop", Args: []string{"app", "--flag"}}
+
+	_, err := PrefixLauncher(launcher, "nice", "-n", "10").Launch(context.Background(), cmd)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"nice", "-n", "10", "app", "--flag"}, launcher.commands[0].Args)
+	assert.Equal(t, "app.desktop", launcher.commands[0].ID)
+	assert.Equal(t, []string{"app", "--flag"}, cmd.Args)
+}
+
+func TestExecLauncher(t *testing.T) {
+	_, err := ExecLauncher{}.Launch(context.Background(), &Command{})
+	assert.NotNil(t, err)
+
+	if runtime.GOOS == "windows" {
+		t.Skip("Shell not available")
+	}
+	dir := t.TempDir()
+	out := filepath.Join(dir, "pwd")
+
+	p, err := ExecLauncher{}.Launch(context.Background(), &Command{Args: []string{"/bin/sh", "-c", `pwd > "$0"`, out}, Dir: dir})
+	assert.Nil(t, err)
+	assert.Nil(t, p.Wait())
+
+	content, err := os.ReadFile(out)
+	assert.Nil(t, err)
+	resolved, _ := filepath.EvalSymlinks(dir)
+	assert.Equal(t, resolved, strings.TrimSpace(string(content)))
+}
+
+func TestProviderOptions_commandLauncher(t *testing.T) {
+	opts := newProviderOptions(nil)
+	assert.Equal(t, ExecLauncher{}, opts.commandLauncher())
+
+	opts = newProviderOptions([]Option{WithDetachedProcesses()})
+	assert.Equal(t, ExecLauncher{Detach: true}, opts.commandLauncher())
+
+	launcher := &fakeLauncher{}
+	opts = newProviderOptions([]Option{WithLauncher(launcher), WithDetachedProcesses()})
+	assert.Equal(t, launcher, opts.commandLauncher())
+}
+
+func TestCommand_String(t *testing.T) {
+	cmd := &Command{Args: []string{"/usr/bin/app", "--title=My App", "", `say "$HOME"`, "a\\b"}}
+	assert.Equal(t, `/usr/bin/app "--title=My App" "" "say \"\$HOME\"" "a\\b"`, cmd.String())
+
+	args, err := SplitExec(cmd.String())
+	assert.Nil(t, err)
+	assert.Equal(t, cmd.Args, args)
+}
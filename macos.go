@@ -2,11 +2,13 @@ package appie
 
 import (
 	"bytes"
+	"context"
 	_ "image/jpeg" // support JPEG images
 	"image/png"    // PNG support is required as we use it directly
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/jackmordaunt/icns"
@@ -20,22 +22,52 @@ type macOSAppBundle struct {
 	// TODO alternateNames []string
 	Executable string `plist:"CFBundleExecutable"`
 
+	BundleID   string `plist:"CFBundleIdentifier"`
+	BundleName string `plist:"CFBundleName"`
+	InfoString string `plist:"CFBundleGetInfoString"`
+	Copyright  string `plist:"NSHumanReadableCopyright"`
+
 	categories []string
 	runPath    string
 	IconFile   string `plist:"CFBundleIconFile"`
 	iconPath   string
 
 	iconCache fyne.Resource
+	launcher  Launcher
 }
 
 func (m *macOSAppBundle) Actions() []Action {
 	return nil
 }
 
+func (m *macOSAppBundle) ID() string {
+	return m.BundleID
+}
+
+func (m *macOSAppBundle) Type() AppType {
+	return TypeApplication
+}
+
 func (m *macOSAppBundle) Name() string {
 	return m.DisplayName
 }
 
+func (m *macOSAppBundle) GenericName() string {
+	return m.BundleName
+}
+
+func (m *macOSAppBundle) Comment() string {
+	if m.InfoString != "" {
+		return m.InfoString
+	}
+
+	return m.Copyright
+}
+
+func (m *macOSAppBundle) Keywords() []string {
+	return nil
+}
+
 func (m *macOSAppBundle) Categories() []string {
 	return m.categories
 }
@@ -44,6 +76,15 @@ func (m *macOSAppBundle) Hidden() bool {
 	return false
 }
 
+func (m *macOSAppBundle) NoDisplay() bool {
+	return false
+}
+
+func (m *macOSAppBundle) Installed() bool {
+	_, err := os.Stat(m.runPath)
+	return err == nil
+}
+
 func (m *macOSAppBundle) Icon(_ string, _ int) fyne.Resource {
 	if m.iconCache != nil {
 		return m.iconCache
@@ -86,13 +127,62 @@ func (m *macOSAppBundle) Run(env []string) error {
 	return m.RunWithParameters([]string{}, env)
 }
 
-func (m *macOSAppBundle) RunWithParameters(params, _ []string) error {
+func (m *macOSAppBundle) RunContext(ctx context.Context, env []string) error {
+	return m.RunWithParametersContext(ctx, []string{}, env)
+}
+
+func (m *macOSAppBundle) RunWithParameters(params, env []string) error {
+	return m.RunWithParametersContext(context.Background(), params, env)
+}
+
+func (m *macOSAppBundle) RunWithParametersContext(ctx context.Context, params, env []string) error {
+	_, err := m.LaunchContext(ctx, params, env)
+	return err
+}
+
+// Launch opens the app bundle with the "open" command, so that an app that is already running is brought to the front
+// and files are passed to the app by the system. The process returned is "open", which exits once the app has been opened,
+// not the app itself.
+func (m *macOSAppBundle) Launch(params, env []string) ([]*Process, error) {
+	return m.LaunchContext(context.Background(), params, env)
+}
+
+// LaunchContext opens the app bundle in the same way as Launch, unless the context has already ended
+func (m *macOSAppBundle) LaunchContext(ctx context.Context, params, env []string) ([]*Process, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	cmds, err := m.Resolve(params, env)
+	if err != nil {
+		return nil, err
+	}
+	cmd := cmds[0]
+
+	launcher := m.launcher
+	if launcher == nil {
+		launcher = ExecLauncher{}
+	}
+	p, err := launcher.Launch(ctx, cmd)
+	if err != nil {
+		return nil, err
+	}
+	return []*Process{p}, nil
+}
+
+// Resolve returns the "open" command that RunWithParameters would pass to the Launcher, without starting it
+func (m *macOSAppBundle) Resolve(params, _ []string) ([]*Command, error) {
 	// in macOS test mode we ignore the wm env flags
-	if len(params) == 0 {
-		return exec.Command("open", "-a", m.runPath).Start()
+	cmd := &Command{ID: m.BundleID, Args: []string{"open", "-a", m.runPath}}
+	if len(params) > 0 {
+		cmd.Args = append(cmd.Args, params[0])
 	}
+	return []*Command{cmd}, nil
+}
 
-	return exec.Command("open", "-a", m.runPath, params[0]).Start()
+func (m *macOSAppBundle) WorkingDir() string {
+	// apps are started by LaunchServices through "open", so we cannot choose where they run
+	return ""
 }
 
 func (m *macOSAppBundle) Source() *AppSource {
@@ -123,9 +213,22 @@ func loadAppBundle(name, path, category string) AppData {
 	return &data
 }
 
+// macOSProcessPath returns the path of the executable for a running process
+func macOSProcessPath(pid int) (string, error) {
+	out, err := exec.Command("ps", "-o", "comm=", "-p", strconv.Itoa(pid)).Output()
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(out)), nil
+}
+
 type macOSAppProvider struct {
 	rootDirs []string
 	cache    *appCache
+	opts     providerOptions
+
+	processPath func(pid int) (string, error)
 }
 
 func (m *macOSAppProvider) forEachApplication(f func(name, path, category string) bool) {
@@ -152,7 +255,8 @@ func (m *macOSAppProvider) AvailableApps() []AppData {
 	var icons []AppData
 	m.forEachApplication(func(name, path, category string) bool {
 		app := loadAppBundle(name, path, category)
-		if app != nil {
+		if app != nil && (m.opts.showUninstalled || app.Installed()) {
+			app.(*macOSAppBundle).launcher = m.opts.commandLauncher()
 			icons = append(icons, app)
 		}
 		return false
@@ -160,6 +264,11 @@ func (m *macOSAppProvider) AvailableApps() []AppData {
 	return icons
 }
 
+func (m *macOSAppProvider) AvailableDirectories() []AppData {
+	// App folders are just directories on macOS, they are not described by metadata
+	return nil
+}
+
 func (m *macOSAppProvider) AvailableThemes() []string {
 	// I'm not sure this is relevant on Mac OSX
 	return []string{}
@@ -169,6 +278,24 @@ func (m *macOSAppProvider) ClearCache() {
 	m.cache.clearCache()
 }
 
+func (m *macOSAppProvider) FindAppByID(id string) AppData {
+	if id == "" {
+		return nil
+	}
+
+	var icon AppData
+	m.cache.forEachCachedApplication(func(_ string, app AppData) bool {
+		if app.ID() == id {
+			icon = app
+			return true
+		}
+
+		return false
+	})
+
+	return icon
+}
+
 func (m *macOSAppProvider) FindAppFromName(appName string) AppData {
 	var icon AppData
 	m.cache.forEachCachedApplication(func(name string, app AppData) bool {
@@ -183,6 +310,30 @@ func (m *macOSAppProvider) FindAppFromName(appName string) AppData {
 	return icon
 }
 
+// FindAppFromPID returns the app bundle that contains the executable of the process with the specified PID
+func (m *macOSAppProvider) FindAppFromPID(pid int) AppData {
+	if pid <= 0 {
+		return nil
+	}
+	path, err := m.processPath(pid)
+	if err != nil || path == "" {
+		return nil
+	}
+
+	var icon AppData
+	m.cache.forEachCachedApplication(func(_ string, app AppData) bool {
+		bundleDir := filepath.Dir(filepath.Dir(filepath.Dir(app.(*macOSAppBundle).runPath)))
+		if strings.HasPrefix(path, bundleDir+string(filepath.Separator)) {
+			icon = app
+			return true
+		}
+
+		return false
+	})
+
+	return icon
+}
+
 func (m *macOSAppProvider) DefaultApps() []AppData {
 	var apps []AppData
 
@@ -226,12 +377,13 @@ func (m *macOSAppProvider) CategorizedApps() map[string][]AppData {
 	}
 }
 
-// NewMacOSProvider creates an instance of a Provider that can find and decode macOS apps
-func NewMacOSProvider() Provider {
+// NewMacOSProvider creates an instance of a Provider that can find and decode macOS apps.
+// Any options passed will configure how apps are looked up.
+func NewMacOSProvider(opts ...Option) Provider {
 	source := &macOSAppProvider{rootDirs: []string{
 		"/Applications", "/Applications/Utilities",
 		"/System/Applications", "/System/Applications/Utilities",
-	}}
+	}, opts: newProviderOptions(opts), processPath: macOSProcessPath}
 	source.cache = newAppCache(source)
 	return source
 }
@@ -12,6 +12,10 @@ func TestLoadAppBundle(t *testing.T) {
 
 	assert.NotNil(t, app)
 	assert.Equal(t, "Test", app.Name())
+	assert.Equal(t, "Test App", app.GenericName())
+	assert.Equal(t, "Copyright © 2024 Fysh", app.Comment())
+	assert.True(t, app.Installed())
+	assert.Equal(t, "io.fyne.test", app.ID())
 	assert.NotNil(t, app.Icon("", 0))
 }
 
@@ -23,3 +27,23 @@ func TestMacOSAppProvider_FindAppFromName(t *testing.T) {
 	assert.NotNil(t, app)
 	assert.Equal(t, "Test", app.Name())
 }
+
+func TestMacOSAppProvider_FindAppByID(t *testing.T) {
+	provider := NewMacOSProvider()
+	provider.(*macOSAppProvider).rootDirs = []string{"testdata"}
+
+	app := provider.FindAppByID("io.fyne.test")
+	assert.NotNil(t, app)
+	assert.Equal(t, "Test", app.Name())
+	assert.Nil(t, provider.FindAppByID("io.fyne.missing"))
+}
+
+func TestMacOSAppBundle_Resolve(t *testing.T) {
+	app := loadAppBundle("Test", "testdata/Test.app", "Applications")
+
+	cmds, err := app.Resolve([]string{"file.txt"}, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(cmds))
+	assert.Equal(t, "io.fyne.test", cmds[0].ID)
+	assert.Equal(t, []string{"open", "-a", app.(*macOSAppBundle).runPath, "file.txt"}, cmds[0].Args)
+}
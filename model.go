@@ -1,6 +1,7 @@
 package appie
 
 import (
+	"context"
 	"runtime"
 
 	"fyne.io/fyne/v2"
@@ -8,19 +9,48 @@ import (
 
 // AppData is an interface for accessing information about application icons
 type AppData interface {
-	Name() string                               // Name is the name of the app usually
-	Run([]string) error                         // Run is the command to run the app, passing any environment variables to be set
-	RunWithParameters([]string, []string) error // RunWithParameters is the command to run the app, passing command line parameters and setting any specified environment variables
+	ID() string                                     // ID is the unique identifier of the app, such as its desktop file ID or bundle identifier
+	Type() AppType                                  // Type is the kind of entry, such as an application or a link
+	Name() string                                   // Name is the name of the app usually
+	Run([]string) error                             // Run is the command to run the app, passing any environment variables to be set
+	RunWithParameters([]string, []string) error     // RunWithParameters is the command to run the app, passing command line parameters and setting any specified environment variables
+	Launch([]string, []string) ([]*Process, error)  // Launch runs the app like RunWithParameters, returning a handle for each process started
+	Resolve([]string, []string) ([]*Command, error) // Resolve returns the commands RunWithParameters would start, without starting them
+
+	// The Context variants give up on launching the app, and return the context error, if it ends before the app starts.
+	// Once started an app is not affected by the context ending.
+	RunContext(context.Context, []string) error
+	RunWithParametersContext(context.Context, []string, []string) error
+	LaunchContext(context.Context, []string, []string) ([]*Process, error)
+
+	GenericName() string // GenericName is a generic description of the app, for example "Web Browser"
+	Comment() string     // Comment is a short description of the app, suitable for a tooltip
+	Keywords() []string  // Keywords is a list of additional words that describe the app, useful for searching
 
 	Categories() []string                      // Categories is a list of categories that the app fits in (platform specific)
 	Hidden() bool                              // Hidden specifies whether instances of this app should be hidden
+	NoDisplay() bool                           // NoDisplay specifies that the app can be launched but should not be listed in menus
+	Installed() bool                           // Installed reports whether the executable for this app is present
 	Icon(theme string, size int) fyne.Resource // Icon returns an icon for the app in the requested theme and size
 	MimeTypes() []string                       // MimeTypes returns a list of mimetypes that this application can handle
+	WorkingDir() string                        // WorkingDir is the directory the app should be run in, or empty for the current directory
 
 	Source() *AppSource // Source will return the location of the app source code from metadata, if known
 	Actions() []Action
 }
 
+// AppType describes the kind of entry that an AppData represents
+type AppType string
+
+const (
+	// TypeApplication is an app that can be run
+	TypeApplication AppType = "Application"
+	// TypeLink is a link to a URL, running it will open the URL with the default handler
+	TypeLink AppType = "Link"
+	// TypeDirectory describes a menu folder, it cannot be run
+	TypeDirectory AppType = "Directory"
+)
+
 // AppSource represents the source code information of an application
 type AppSource struct {
 	Repo, Dir string
@@ -29,9 +59,13 @@ type AppSource struct {
 // Provider describes a type that can locate icons and applications for the current system
 type Provider interface {
 	AvailableApps() []AppData
+	AvailableDirectories() []AppData
 	AvailableThemes() []string
+	FindAppByID(id string) AppData
 	FindAppFromName(appName string) AppData
+	FindAppFromPID(pid int) AppData
 	FindAppsMatching(pattern string) []AppData
+	FindAppsMatchingWindow(class string) []AppMatch
 	DefaultApps() []AppData
 	CategorizedApps() map[string][]AppData
 
@@ -41,16 +75,22 @@ type Provider interface {
 type Action interface {
 	Name() string
 	Run(env []string) error
+	Launch(env []string) (*Process, error)  // Launch runs the action like Run, returning a handle to the process
+	Resolve(env []string) (*Command, error) // Resolve returns the command Run would start, without starting it
+
+	RunContext(ctx context.Context, env []string) error                // RunContext runs the action, giving up if the context ends first
+	LaunchContext(ctx context.Context, env []string) (*Process, error) // LaunchContext launches the action, giving up if the context ends first
 }
 
 // SystemProvider returns an application provider for the current system.
 // for macOS systems it will be a macOSProvider, for Linux/Unix it will be an FDOProvider.
-func SystemProvider() Provider {
+// Any options passed will be used to configure the provider.
+func SystemProvider(opts ...Option) Provider {
 	switch runtime.GOOS {
 	case "darwin":
-		return NewMacOSProvider()
+		return NewMacOSProvider(opts...)
 	case "linux", "freebsd", "openbsd", "netbsd", "dragonfly":
-		return NewFDOProvider()
+		return NewFDOProvider(opts...)
 	}
 
 	return nil
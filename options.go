@@ -0,0 +1,120 @@
+package appie
+
+import "io"
+
+// Option configures a Provider when it is created.
+type Option func(*providerOptions)
+
+type providerOptions struct {
+	locale          string
+	desktops        []string
+	terminal        []string
+	showUninstalled bool
+	launcher        Launcher
+	systemdScopes   bool
+	detach          bool
+	logDir          string
+	logWriter       io.Writer
+}
+
+// WithLocale sets the locale used to look up translated app information, overriding the environment.
+// The locale is in the form lang_COUNTRY.ENCODING@MODIFIER, where all but lang are optional.
+func WithLocale(locale string) Option {
+	return func(o *providerOptions) {
+		o.locale = locale
+	}
+}
+
+// WithCurrentDesktop sets the names of the current desktop environment, overriding XDG_CURRENT_DESKTOP.
+// Apps are filtered by their OnlyShowIn and NotShowIn keys using these names, in order of preference.
+func WithCurrentDesktop(desktops ...string) Option {
+	return func(o *providerOptions) {
+		o.desktops = append([]string{}, desktops...)
+	}
+}
+
+// WithTerminal sets the command used to run apps that require a terminal, for example "foot", "-e".
+// The command line of the app will be appended to the arguments passed.
+func WithTerminal(command ...string) Option {
+	return func(o *providerOptions) {
+		o.terminal = command
+	}
+}
+
+// WithUninstalledApps includes apps whose executable cannot be found when listing or searching.
+// This is useful for diagnosing broken installations, apps will report false for AppData.Installed().
+func WithUninstalledApps() Option {
+	return func(o *providerOptions) {
+		o.showUninstalled = true
+	}
+}
+
+// WithLauncher sets the Launcher used to start apps and actions, replacing the default ExecLauncher.
+// This allows apps to be run in a different way, for example PrefixLauncher(nil, "nice") will lower their priority.
+func WithLauncher(l Launcher) Option {
+	return func(o *providerOptions) {
+		o.launcher = l
+	}
+}
+
+// WithSystemdScopes runs each app that is launched in its own systemd scope, as GNOME and KDE do.
+// This allows resources to be accounted for each app and apps to be stopped cleanly at logout.
+func WithSystemdScopes() Option {
+	return func(o *providerOptions) {
+		o.systemdScopes = true
+	}
+}
+
+// WithDetachedProcesses starts apps so that they keep running when the launcher is stopped or restarted.
+// This configures the default ExecLauncher, see ExecLauncher.Detach, and has no effect if WithLauncher is used.
+func WithDetachedProcesses() Option {
+	return func(o *providerOptions) {
+		o.detach = true
+	}
+}
+
+// WithLogDir records the output of each app launched, and details of how it was launched, in log files in dir.
+// See LogFileLauncher for details of how the logs are written.
+func WithLogDir(dir string) Option {
+	return func(o *providerOptions) {
+		o.logDir = dir
+	}
+}
+
+// WithLogWriter writes the output of each app launched, and details of how it was launched, to w.
+// See LogWriterLauncher for details of how the log is written.
+// If used WithDetachedProcesses only the details are written, as copying the output of apps
+// would cause them to be stopped when the launcher exits.
+func WithLogWriter(w io.Writer) Option {
+	return func(o *providerOptions) {
+		o.logWriter = w
+	}
+}
+
+// commandLauncher returns the Launcher that should start apps
+func (o *providerOptions) commandLauncher() Launcher {
+	launcher := o.launcher
+	if launcher == nil {
+		launcher = ExecLauncher{Detach: o.detach}
+	}
+
+	if o.logDir != "" {
+		launcher = LogFileLauncher(launcher, o.logDir)
+	}
+	if o.logWriter != nil {
+		launcher = newLogWriterLauncher(launcher, o.logWriter, o.detach)
+	}
+
+	if o.systemdScopes {
+		return SystemdScopeLauncher(launcher, nil)
+	}
+	return launcher
+}
+
+func newProviderOptions(opts []Option) providerOptions {
+	var o providerOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
@@ -0,0 +1,254 @@
+package appie
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+)
+
+const (
+	// logFileMaxSize is the size that a log file can reach before it is rotated when the app next launches
+	logFileMaxSize = 1 << 20
+	// logFileBackups is the number of rotated log files kept for each app
+	logFileBackups = 3
+)
+
+// logLauncher captures the output of apps that are started by the next Launcher, along with details of each launch
+type logLauncher struct {
+	next Launcher
+	open func(id string) (io.Writer, func(), error)
+
+	detailsOnly bool // only record launch details, leaving the output of apps as the command specifies
+}
+
+// LogFileLauncher returns a Launcher that records the standard output and error of each app in a log file in dir,
+// named after the ID of the app. The command, changes to the environment, PID and exit status are also recorded.
+// Log files larger than 1MiB are rotated when the app is next launched, keeping 3 older logs.
+// Apps write to the log file directly, unless the command also has a writer for its output,
+// in which case that output is copied through a pipe by the launcher, as LogWriterLauncher does.
+// Commands are started by next, or an ExecLauncher if nil.
+func LogFileLauncher(next Launcher, dir string) Launcher {
+	return newLogLauncher(next, func(id string) (io.Writer, func(), error) {
+		file, err := openLogFile(dir, id, logFileMaxSize)
+		if err != nil {
+			return nil, nil, err
+		}
+		return file, func() { _ = file.Close() }, nil
+	})
+}
+
+// LogWriterLauncher returns a Launcher that writes the standard output and error of each app to w,
+// along with the command, changes to the environment, PID and exit status of each launch.
+// Writes are serialised so that w can be shared by many apps, but output of apps running at the same time may be interleaved.
+// Output is read from a pipe and copied to w by the launcher. When the launcher exits the pipe is closed,
+// and an app that then writes output is sent SIGPIPE, which stops most apps.
+// This means apps launched this way do not outlive the launcher, even if they were detached from it.
+// Commands are started by next, or an ExecLauncher if nil.
+func LogWriterLauncher(next Launcher, w io.Writer) Launcher {
+	return newLogWriterLauncher(next, w, false)
+}
+
+// newLogWriterLauncher returns a Launcher that writes details of each launch to w, and the output of apps unless detailsOnly is set
+func newLogWriterLauncher(next Launcher, w io.Writer, detailsOnly bool) Launcher {
+	out := &syncWriter{w: w}
+	l := newLogLauncher(next, func(string) (io.Writer, func(), error) {
+		return out, func() {}, nil
+	})
+	l.detailsOnly = detailsOnly
+	return l
+}
+
+func newLogLauncher(next Launcher, open func(id string) (io.Writer, func(), error)) *logLauncher {
+	if next == nil {
+		next = ExecLauncher{}
+	}
+
+	return &logLauncher{next: next, open: open}
+}
+
+// Launch starts the command with its output captured, as well as being written to any output the command has,
+// unless only the details of the launch are recorded.
+// If the log cannot be opened the app is started without it.
+func (l *logLauncher) Launch(ctx context.Context, cmd *Command) (*Process, error) {
+	out, done, err := l.open(cmd.ID)
+	if err != nil {
+		fyne.LogError("Unable to open log for app "+cmd.ID, err)
+		return l.next.Launch(ctx, cmd)
+	}
+
+	outputs := &logOutputs{}
+	logged := *cmd
+	if !l.detailsOnly {
+		logged.Stdout, err = outputs.open(cmd.Stdout, out)
+		if err == nil {
+			if cmd.Stderr == cmd.Stdout {
+				logged.Stderr = logged.Stdout // share the file so that output stays in order, as exec.Cmd does
+			} else {
+				logged.Stderr, err = outputs.open(cmd.Stderr, out)
+			}
+		}
+	}
+	if err != nil {
+		fyne.LogError("Unable to capture output for app "+cmd.ID, err)
+		outputs.close()
+		outputs.copies.Wait()
+		done()
+		return l.next.Launch(ctx, cmd)
+	}
+
+	writeLaunchDetails(out, cmd)
+	p, err := l.next.Launch(ctx, &logged)
+	outputs.close()
+	if err != nil {
+		fmt.Fprintf(out, "--- %s failed to launch: %v\n", cmd.ID, err)
+		outputs.copies.Wait()
+		done()
+		return nil, err
+	}
+
+	fmt.Fprintf(out, "pid: %d\n", p.PID())
+	go func() {
+		status := "exit status 0"
+		if err := p.Wait(); err != nil {
+			status = err.Error()
+		}
+		fmt.Fprintf(out, "--- %s (pid %d) %s\n", cmd.ID, p.PID(), status)
+		outputs.copies.Wait() // keep the log open until processes sharing the output have exited
+		done()
+	}()
+	return p, nil
+}
+
+// logOutputs are the files that a process writes its output to while it is logged.
+// When output cannot be written to a log file directly it is written to a pipe that is copied by a goroutine,
+// rather than passing exec.Cmd a writer, so that waiting for the process does not also wait for
+// any processes it started that inherited the pipe, and the copy continues until they have all exited.
+type logOutputs struct {
+	pipes  []*os.File // the ends of pipes written by the process, closed once it has started
+	copies sync.WaitGroup
+}
+
+// open returns the file that a process should write one of its outputs to
+func (o *logOutputs) open(existing, out io.Writer) (*os.File, error) {
+	if file, ok := out.(*os.File); ok && existing == nil {
+		return file, nil
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	o.pipes = append(o.pipes, w)
+	o.copies.Add(1)
+	go func() {
+		defer o.copies.Done()
+		_, _ = io.Copy(teeWriter(existing, out), r)
+		_ = r.Close()
+	}()
+	return w, nil
+}
+
+// close closes the launcher's copy of the pipe ends that were passed to the process, so that copying ends when it exits
+func (o *logOutputs) close() {
+	for _, w := range o.pipes {
+		_ = w.Close()
+	}
+}
+
+// teeWriter returns a writer for process output that also writes to the existing writer, if there is one
+func teeWriter(existing, out io.Writer) io.Writer {
+	if existing == nil {
+		return out
+	}
+
+	return io.MultiWriter(existing, out)
+}
+
+// writeLaunchDetails records the time, command and changes to the environment of a launch
+func writeLaunchDetails(w io.Writer, cmd *Command) {
+	fmt.Fprintf(w, "--- %s launching %s\n", time.Now().Format(time.RFC3339), cmd.ID)
+	fmt.Fprintf(w, "command: %s\n", cmd.String())
+	if cmd.Dir != "" {
+		fmt.Fprintf(w, "dir: %s\n", cmd.Dir)
+	}
+	if cmd.Env != nil {
+		if diff := envDiff(os.Environ(), cmd.Env); len(diff) > 0 {
+			fmt.Fprintf(w, "env: %s\n", strings.Join(diff, " "))
+		}
+	}
+}
+
+// envDiff returns the variables in env that are new or changed from base,
+// followed by the names of those that were removed prefixed with "-".
+func envDiff(base, env []string) []string {
+	values := make(map[string]string, len(base))
+	for _, item := range base {
+		name, value, _ := strings.Cut(item, "=")
+		values[name] = value
+	}
+
+	var diff []string
+	set := make(map[string]bool, len(env))
+	for _, item := range env {
+		name, value, _ := strings.Cut(item, "=")
+		set[name] = true
+		if old, ok := values[name]; !ok || old != value {
+			diff = append(diff, item)
+		}
+	}
+	for _, item := range base {
+		name, _, _ := strings.Cut(item, "=")
+		if !set[name] {
+			diff = append(diff, "-"+name)
+		}
+	}
+	return diff
+}
+
+// openLogFile opens the log for an app to append to, rotating it first if it is larger than maxSize
+func openLogFile(dir, id string, maxSize int64) (*os.File, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSuffix(id, ".desktop")
+	if name == "" {
+		name = "app"
+	}
+	path := filepath.Join(dir, strings.ReplaceAll(name, string(filepath.Separator), "_")+".log")
+	if info, err := os.Stat(path); err == nil && info.Size() > maxSize {
+		rotateLogFiles(path, logFileBackups)
+	}
+
+	return os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+}
+
+// rotateLogFiles moves a log file to path.1, moving older logs up to path.<backups> and removing the oldest
+func rotateLogFiles(path string, backups int) {
+	_ = os.Remove(path + "." + strconv.Itoa(backups))
+	for i := backups - 1; i > 0; i-- {
+		_ = os.Rename(path+"."+strconv.Itoa(i), path+"."+strconv.Itoa(i+1))
+	}
+	_ = os.Rename(path, path+".1")
+}
+
+// syncWriter serialises writes to a writer that is shared by many processes
+type syncWriter struct {
+	lock sync.Mutex
+	w    io.Writer
+}
+
+func (s *syncWriter) Write(p []byte) (int, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.w.Write(p)
+}
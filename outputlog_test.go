@@ -0,0 +1,164 @@
+package appie
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// lockedBuffer is a buffer that can be read while processes are writing to it
+type lockedBuffer struct {
+	lock sync.Mutex
+	buf  bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.String()
+}
+
+func TestLogWriterLauncher(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Shell not available")
+	}
+	out := &lockedBuffer{}
+	env := append(os.Environ(), "APPIE_TEST=1")
+
+	// the background process keeps the output open, this should not stop Wait returning
+	start := time.Now()
+	p, err := LogWriterLauncher(nil, out).Launch(context.Background(),
+		&Command{ID: "test.desktop", Args: []string{"/bin/sh", "-c", "echo hello; echo oops >&2; sleep 2 & exit 2"}, Env: env})
+	assert.Nil(t, err)
+	assert.NotNil(t, p.Wait())
+	assert.Less(t, time.Since(start), 2*time.Second)
+
+	assert.Eventually(t, func() bool {
+		return strings.Contains(out.String(), "oops\n") && strings.Contains(out.String(), "exit status 2")
+	}, time.Second, 10*time.Millisecond)
+	log := out.String()
+	assert.Contains(t, log, "launching test.desktop\n")
+	assert.Contains(t, log, `command: /bin/sh -c "echo hello; echo oops >&2; sleep 2 & exit 2"`+"\n")
+	assert.Contains(t, log, "env: APPIE_TEST=1\n")
+	assert.Contains(t, log, "hello\noops\n")
+	assert.Contains(t, log, "--- test.desktop (pid "+strconv.Itoa(p.PID())+") exit status 2\n")
+}
+
+func TestLogWriterLauncher_Failed(t *testing.T) {
+	out := &lockedBuffer{}
+	launcher := &fakeLauncher{err: errors.New("failed")}
+
+	_, err := LogWriterLauncher(launcher, out).Launch(context.Background(), &Command{ID: "test.desktop", Args: []string{"test"}})
+	assert.Equal(t, launcher.err, err)
+	assert.Contains(t, out.String(), "--- test.desktop failed to launch: failed\n")
+}
+
+func TestLogWriterLauncher_Detached(t *testing.T) {
+	out := &lockedBuffer{}
+	launcher := &fakeLauncher{}
+
+	// detached apps keep their output, so they are not tied to a pipe read by the launcher
+	opts := newProviderOptions([]Option{WithLauncher(launcher), WithDetachedProcesses(), WithLogWriter(out)})
+	p, err := opts.commandLauncher().Launch(context.Background(), &Command{ID: "test.desktop", Args: []string{"test"}})
+	assert.Nil(t, err)
+	<-p.Done()
+
+	assert.Nil(t, launcher.commands[0].Stdout)
+	assert.Nil(t, launcher.commands[0].Stderr)
+	assert.Contains(t, out.String(), "launching test.desktop\n")
+	assert.Eventually(t, func() bool {
+		return strings.Contains(out.String(), "--- test.desktop (pid 1001) exit status 0\n")
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestLogFileLauncher(t *testing.T) {
+	dir := t.TempDir()
+	launcher := &fakeLauncher{}
+
+	p, err := LogFileLauncher(launcher, dir).Launch(context.Background(), &Command{ID: "test.desktop", Args: []string{"test"}})
+	assert.Nil(t, err)
+	<-p.Done()
+
+	// the app writes to the log file directly
+	stdout, ok := launcher.commands[0].Stdout.(*os.File)
+	assert.True(t, ok)
+	assert.Equal(t, filepath.Join(dir, "test.log"), stdout.Name())
+	assert.Equal(t, launcher.commands[0].Stdout, launcher.commands[0].Stderr)
+	assert.Eventually(t, func() bool {
+		content, _ := os.ReadFile(filepath.Join(dir, "test.log"))
+		return strings.Contains(string(content), "--- test.desktop (pid 1001) exit status 0\n")
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestLogFileLauncher_Writer(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Shell not available")
+	}
+	dir := t.TempDir()
+	out := &lockedBuffer{}
+
+	p, err := LogFileLauncher(nil, dir).Launch(context.Background(),
+		&Command{ID: "test.desktop", Args: []string{"/bin/sh", "-c", "echo hello"}, Stdout: out})
+	assert.Nil(t, err)
+	assert.Nil(t, p.Wait())
+
+	// the output is written to the log file as well as the writer of the command
+	assert.Eventually(t, func() bool {
+		content, _ := os.ReadFile(filepath.Join(dir, "test.log"))
+		return out.String() == "hello\n" && strings.Contains(string(content), "hello\n")
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestOpenLogFile_Rotate(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "test.log")
+	for i, content := range []string{"current", "old1", "old2", "old3"} {
+		name := path
+		if i > 0 {
+			name += "." + strconv.Itoa(i)
+		}
+		assert.Nil(t, os.WriteFile(name, []byte(content), 0o644))
+	}
+
+	// smaller than the maximum size so not rotated
+	file, err := openLogFile(dir, "test.desktop", 100)
+	assert.Nil(t, err)
+	_ = file.Close()
+	content, _ := os.ReadFile(path)
+	assert.Equal(t, "current", string(content))
+
+	file, err = openLogFile(dir, "test.desktop", 4)
+	assert.Nil(t, err)
+	_ = file.Close()
+	for i, expected := range []string{"current", "old1", "old2"} {
+		content, _ = os.ReadFile(path + "." + strconv.Itoa(i+1))
+		assert.Equal(t, expected, string(content))
+	}
+	info, err := os.Stat(path)
+	assert.Nil(t, err)
+	assert.Zero(t, info.Size())
+}
+
+func TestEnvDiff(t *testing.T) {
+	base := []string{"HOME=/home/user", "LANG=C", "TERM=xterm"}
+	env := []string{"HOME=/home/user", "LANG=de_DE", "DISPLAY=:0"}
+
+	assert.Equal(t, []string{"LANG=de_DE", "DISPLAY=:0", "-TERM"}, envDiff(base, env))
+	assert.Nil(t, envDiff(base, base))
+}
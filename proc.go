@@ -0,0 +1,218 @@
+package appie
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// fdoProcRoot is where process information is found on Linux systems
+const fdoProcRoot = "/proc"
+
+// fdoInterpreters are programs that run an app passed as their first argument
+var fdoInterpreters = []string{"bash", "env", "gjs", "java", "node", "perl", "python", "ruby", "sh"}
+
+// FindAppFromPID returns the app that is running as the process with the specified PID, or nil if not known.
+// The desktop file recorded in the environment of the process is used if available,
+// otherwise Flatpak and Snap metadata are checked before matching the executable to an Exec line.
+func (f *fdoIconProvider) FindAppFromPID(pid int) AppData {
+	if pid <= 0 {
+		return nil
+	}
+	dir := filepath.Join(f.procRoot, strconv.Itoa(pid))
+
+	env := readProcStrings(filepath.Join(dir, "environ"))
+	if app := f.findAppFromProcEnv(env); app != nil {
+		return app
+	}
+	if app := f.findAppFromFlatpak(filepath.Join(dir, "root", ".flatpak-info")); app != nil {
+		return app
+	}
+
+	exe, _ := os.Readlink(filepath.Join(dir, "exe"))
+	return f.findAppFromCommand(exe, readProcStrings(filepath.Join(dir, "cmdline")))
+}
+
+func (f *fdoIconProvider) findAppFromProcEnv(env []string) AppData {
+	for _, name := range []string{"GIO_LAUNCHED_DESKTOP_FILE", "BAMF_DESKTOP_FILE_HINT"} {
+		path := lookupEnv(env, name)
+		if path == "" {
+			continue
+		}
+
+		if app := f.findAppFromPath(path); app != nil {
+			return app
+		}
+	}
+
+	if id := lookupEnv(env, "FLATPAK_ID"); id != "" {
+		if app := f.FindAppByID(id); app != nil {
+			return app
+		}
+	}
+	if snap := lookupEnv(env, "SNAP_NAME"); snap != "" {
+		return f.findAppFromSnap(snap)
+	}
+	return nil
+}
+
+// findAppFromPath returns the app loaded from a desktop file, or with the same desktop file ID if it was shadowed
+func (f *fdoIconProvider) findAppFromPath(path string) AppData {
+	var found AppData
+	f.cache.forEachCachedApplication(func(_ string, app AppData) bool {
+		if app.(*fdoApplicationData).path == path {
+			found = app
+			return true
+		}
+		return false
+	})
+	if found != nil {
+		return found
+	}
+
+	return f.FindAppByID(fdoDesktopFileIDForPath(path))
+}
+
+func (f *fdoIconProvider) findAppFromFlatpak(infoPath string) AppData {
+	info, err := LoadDesktopFile(infoPath)
+	if err != nil {
+		return nil
+	}
+	app := info.Group("Application")
+	if app == nil {
+		return nil
+	}
+
+	return f.FindAppByID(app.String("name"))
+}
+
+// findAppFromSnap returns the app for a snap, desktop files for snaps have IDs in the form <snap>_<app>.desktop
+func (f *fdoIconProvider) findAppFromSnap(snap string) AppData {
+	if app := f.FindAppByID(snap + "_" + snap); app != nil {
+		return app
+	}
+
+	var found AppData
+	f.cache.forEachCachedApplication(func(_ string, app AppData) bool {
+		if strings.HasPrefix(app.ID(), snap+"_") {
+			found = app
+			return true
+		}
+		return false
+	})
+	return found
+}
+
+// findAppFromCommand matches the executable and command line of a process to the Exec line of an app.
+// If the process is an interpreter running a script then only the script is matched,
+// so that apps which run the interpreter itself are not mistaken for the script.
+func (f *fdoIconProvider) findAppFromCommand(exe string, args []string) AppData {
+	var programs []string
+	if len(args) > 1 && isInterpreter(args[0]) {
+		programs = append(programs, args[1])
+	} else {
+		if exe != "" {
+			programs = append(programs, strings.TrimSuffix(exe, " (deleted)"))
+		}
+		if len(args) > 0 {
+			programs = append(programs, args[0])
+		}
+	}
+
+	for _, program := range programs {
+		var found AppData
+		f.cache.forEachCachedApplication(func(_ string, app AppData) bool {
+			cmd := app.(*fdoApplicationData).execScript()
+			if cmd == "" {
+				return false
+			}
+			if cmd == program || (!filepath.IsAbs(cmd) && cmd == filepath.Base(program)) {
+				found = app
+				return true
+			}
+			return false
+		})
+		if found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// execProgram returns the program that the Exec key runs, skipping over any use of env to set variables.
+// If the Exec key cannot be parsed "" is returned.
+func (data *fdoApplicationData) execProgram() string {
+	args := data.execArgs()
+	if len(args) == 0 {
+		return ""
+	}
+	return args[0]
+}
+
+// execScript returns the script that the Exec key runs with an interpreter, or the program if it is not an interpreter
+func (data *fdoApplicationData) execScript() string {
+	args := data.execArgs()
+	if len(args) == 0 {
+		return ""
+	}
+	if len(args) > 1 && isInterpreter(args[0]) {
+		return args[1]
+	}
+	return args[0]
+}
+
+// execArgs returns the command line of the Exec key, without any use of env to set variables
+func (data *fdoApplicationData) execArgs() []string {
+	args, err := SplitExec(data.exec)
+	if err != nil || len(args) == 0 {
+		return nil
+	}
+
+	if filepath.Base(args[0]) == "env" {
+		args = args[1:]
+		for len(args) > 0 && (strings.HasPrefix(args[0], "-") || strings.Contains(args[0], "=")) {
+			if (args[0] == "-u" || args[0] == "--unset") && len(args) > 1 {
+				args = args[1:] // skip the variable name too
+			}
+			args = args[1:]
+		}
+	}
+	return args
+}
+
+func isInterpreter(program string) bool {
+	name := strings.TrimRight(filepath.Base(program), "0123456789.")
+	for _, interpreter := range fdoInterpreters {
+		if name == interpreter {
+			return true
+		}
+	}
+	return false
+}
+
+// readProcStrings returns the NUL separated strings of a file such as /proc/<pid>/cmdline
+func readProcStrings(path string) []string {
+	data, err := os.ReadFile(path)
+	if err != nil || len(data) == 0 {
+		return nil
+	}
+
+	var items []string
+	for _, item := range bytes.Split(bytes.TrimRight(data, "\x00"), []byte{0}) {
+		items = append(items, string(item))
+	}
+	return items
+}
+
+// lookupEnv returns the value of a variable in env. If it is set more than once the last value is used,
+// as that is the one a process started with the environment would see.
+func lookupEnv(env []string, name string) string {
+	for i := len(env) - 1; i >= 0; i-- {
+		if strings.HasPrefix(env[i], name+"=") {
+			return env[i][len(name)+1:]
+		}
+	}
+	return ""
+}
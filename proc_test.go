@@ -0,0 +1,112 @@
+package appie
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeProcFile(t *testing.T, root, pid, name string, items ...string) {
+	dir := filepath.Join(root, pid, filepath.Dir(name))
+	assert.Nil(t, os.MkdirAll(dir, 0o755))
+
+	content := strings.Join(items, "\x00") + "\x00"
+	assert.Nil(t, os.WriteFile(filepath.Join(root, pid, name), []byte(content), 0o644))
+}
+
+// applications/python3.11.desktop runs the interpreter, so should not match scripts that it runs
+func TestFdoIconProvider_FindAppFromPID(t *testing.T) {
+	setTestEnv(t)
+	workingDir, _ := os.Getwd()
+	root := t.TempDir()
+	provider := NewFDOProvider().(*fdoIconProvider)
+	provider.procRoot = root
+
+	writeProcFile(t, root, "100", "environ", "HOME=/home/user",
+		"GIO_LAUNCHED_DESKTOP_FILE="+filepath.Join(workingDir, "testdata", "applications", "app5.desktop"))
+	writeProcFile(t, root, "101", "environ", "BAMF_DESKTOP_FILE_HINT=/var/lib/snapd/desktop/applications/xterm.desktop")
+	writeProcFile(t, root, "102", "environ", "FLATPAK_ID=org.fyne.Calculator")
+	assert.Nil(t, os.MkdirAll(filepath.Join(root, "103", "root"), 0o755))
+	err := os.WriteFile(filepath.Join(root, "103", "root", ".flatpak-info"), []byte("[Application]\nname=org.fyne.Calculator\n"), 0o644)
+	assert.Nil(t, err)
+	writeProcFile(t, root, "104", "cmdline", "python3.11", "/usr/bin/app6", "--flag")
+	writeProcFile(t, root, "105", "cmdline", "/usr/local/bin/app7", "file.txt")
+	writeProcFile(t, root, "106", "cmdline", "python3.11")
+
+	assert.Equal(t, "App5", provider.FindAppFromPID(100).Name())
+	assert.Equal(t, "XTerm", provider.FindAppFromPID(101).Name())
+	assert.Equal(t, "Calculator", provider.FindAppFromPID(102).Name())
+	assert.Equal(t, "Calculator", provider.FindAppFromPID(103).Name())
+	assert.Equal(t, "App6", provider.FindAppFromPID(104).Name())
+	assert.Equal(t, "App7", provider.FindAppFromPID(105).Name())
+	assert.Equal(t, "Python 3.11", provider.FindAppFromPID(106).Name()) // the interpreter is not running a script
+	assert.Nil(t, provider.FindAppFromPID(107))
+	assert.Nil(t, provider.FindAppFromPID(0))
+}
+
+// applications/kde-only.desktop is not listed in GNOME but can still be running
+func TestFdoIconProvider_FindAppFromPID_NotListed(t *testing.T) {
+	setTestEnv(t)
+	workingDir, _ := os.Getwd()
+	root := t.TempDir()
+	provider := NewFDOProvider(WithCurrentDesktop("GNOME")).(*fdoIconProvider)
+	provider.procRoot = root
+
+	writeProcFile(t, root, "110", "environ",
+		"GIO_LAUNCHED_DESKTOP_FILE="+filepath.Join(workingDir, "testdata", "applications", "kde-only.desktop"))
+	writeProcFile(t, root, "111", "cmdline", "kde-only")
+
+	assert.Equal(t, "KDE Only", provider.FindAppFromPID(110).Name())
+	assert.Nil(t, provider.FindAppFromPID(111)) // only listed apps are matched by their command
+}
+
+func TestFdoIconProvider_FindAppFromPID_Exe(t *testing.T) {
+	setTestEnv(t)
+	root := t.TempDir()
+	provider := NewFDOProvider().(*fdoIconProvider)
+	provider.procRoot = root
+
+	assert.Nil(t, os.MkdirAll(filepath.Join(root, "200"), 0o755))
+	if err := os.Symlink("/opt/app8/app8", filepath.Join(root, "200", "exe")); err != nil {
+		t.Skip("Symlinks not supported", err)
+	}
+	assert.Equal(t, "App8", provider.FindAppFromPID(200).Name())
+}
+
+func TestFdoIconProvider_findAppFromCommand(t *testing.T) {
+	provider := NewFDOProvider().(*fdoIconProvider)
+	python := &fdoApplicationData{name: "Python", exec: "python3"}
+	script := &fdoApplicationData{name: "Script", exec: "python3 /usr/share/script/main.py %F"}
+	provider.cache.appList = []AppData{python, script}
+
+	assert.Equal(t, script, provider.findAppFromCommand("/usr/bin/python3.11", []string{"python3", "/usr/share/script/main.py"}))
+	assert.Equal(t, python, provider.findAppFromCommand("/usr/bin/python3.11", []string{"python3"}))
+	assert.Nil(t, provider.findAppFromCommand("/usr/bin/python3.11", []string{"python3", "/usr/bin/other"}))
+}
+
+func TestFdoApplicationData_execProgram(t *testing.T) {
+	data := &fdoApplicationData{exec: `env -u FOO WINEPREFIX="/home/u/.wine" wine game.exe`}
+	assert.Equal(t, "wine", data.execProgram())
+
+	data = &fdoApplicationData{exec: "/usr/bin/app %U"}
+	assert.Equal(t, "/usr/bin/app", data.execProgram())
+}
+
+func TestMacOSAppProvider_FindAppFromPID(t *testing.T) {
+	provider := NewMacOSProvider().(*macOSAppProvider)
+	provider.rootDirs = []string{"testdata"}
+	provider.processPath = func(pid int) (string, error) {
+		if pid == 300 {
+			return filepath.Join("testdata", "Test.app", "Contents", "MacOS", "test"), nil
+		}
+		return "/usr/bin/other", nil
+	}
+
+	app := provider.FindAppFromPID(300)
+	assert.NotNil(t, app)
+	assert.Equal(t, "Test", app.Name())
+	assert.Nil(t, provider.FindAppFromPID(301))
+}
@@ -0,0 +1,109 @@
+package appie
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+)
+
+// TransientUnitStarter creates transient systemd units, such as the StartTransientUnit D-Bus call does.
+type TransientUnitStarter interface {
+	// StartTransientScope creates a scope unit with the name passed that contains the listed processes
+	StartTransientScope(ctx context.Context, name, description string, pids []int) error
+}
+
+// busctlUnitStarter starts units in the systemd user instance by calling StartTransientUnit with busctl
+type busctlUnitStarter struct{}
+
+func (busctlUnitStarter) StartTransientScope(ctx context.Context, name, description string, pids []int) error {
+	out, err := exec.CommandContext(ctx, "busctl", busctlScopeArgs(name, description, pids)...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to start scope %s: %w %s", name, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// busctlScopeArgs returns the busctl arguments that call StartTransientUnit for a new scope
+func busctlScopeArgs(name, description string, pids []int) []string {
+	args := []string{
+		"--user", "call", "org.freedesktop.systemd1", "/org/freedesktop/systemd1",
+		"org.freedesktop.systemd1.Manager", "StartTransientUnit", "ssa(sv)a(sa(sv))",
+		name, "fail", "2", "Description", "s", description, "PIDs", "au", strconv.Itoa(len(pids)),
+	}
+	for _, pid := range pids {
+		args = append(args, strconv.Itoa(pid))
+	}
+	return append(args, "0") // no auxiliary units
+}
+
+// SystemdScopeLauncher returns a Launcher that moves each app it starts into its own systemd scope,
+// named app-<id>-<random>.scope as desktop environments do, so that it can be tracked and stopped separately.
+// Commands are started by next, or an ExecLauncher if nil, and the scope is created by starter,
+// or by calling StartTransientUnit on the systemd user instance if nil.
+// If the scope cannot be created the error is logged and the app is left running.
+func SystemdScopeLauncher(next Launcher, starter TransientUnitStarter) Launcher {
+	if next == nil {
+		next = ExecLauncher{}
+	}
+	if starter == nil {
+		starter = busctlUnitStarter{}
+	}
+
+	return LauncherFunc(func(ctx context.Context, cmd *Command) (*Process, error) {
+		p, err := next.Launch(ctx, cmd)
+		if err != nil || cmd.ID == "" {
+			return p, err
+		}
+
+		name, err := systemdScopeName(cmd.ID)
+		if err == nil {
+			desc := "Application launched by " + filepath.Base(os.Args[0])
+			err = starter.StartTransientScope(ctx, name, desc, []int{p.PID()})
+		}
+		if err != nil {
+			fyne.LogError("Unable to move app into a systemd scope", err)
+		}
+		return p, nil
+	})
+}
+
+// systemdScopeName returns a unique unit name for a scope that will contain the app with the ID passed
+func systemdScopeName(id string) (string, error) {
+	random := make([]byte, 4)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+
+	id = strings.TrimSuffix(id, ".desktop")
+	return "app-" + systemdEscape(id) + "-" + hex.EncodeToString(random) + ".scope", nil
+}
+
+// systemdEscape escapes a string for use in a unit name, in the same way as systemd-escape
+func systemdEscape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '/':
+			b.WriteByte('-')
+		case c == '.' && i == 0, !isSystemdUnitChar(c):
+			fmt.Fprintf(&b, `\x%02x`, c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+func isSystemdUnitChar(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') ||
+		c == ':' || c == '_' || c == '.'
+}
@@ -0,0 +1,66 @@
+package appie
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeUnitStarter records the scopes it is asked to start
+type fakeUnitStarter struct {
+	names []string
+	pids  [][]int
+	err   error
+}
+
+func (f *fakeUnitStarter) StartTransientScope(_ context.Context, name, _ string, pids []int) error {
+	f.names = append(f.names, name)
+	f.pids = append(f.pids, pids)
+	return f.err
+}
+
+func TestSystemdScopeLauncher(t *testing.T) {
+	setTestEnv(t)
+	setTestPath(t, "app1")
+	starter := &fakeUnitStarter{}
+	provider := NewFDOProvider(WithLauncher(SystemdScopeLauncher(&fakeLauncher{}, starter)))
+
+	app := provider.FindAppByID("app1")
+	assert.Nil(t, app.Run(nil))
+	assert.Nil(t, app.Actions()[0].Run(nil))
+
+	assert.Equal(t, 2, len(starter.names))
+	assert.Regexp(t, regexp.MustCompile(`^app-app1-[0-9a-f]{8}\.scope$`), starter.names[0])
+	assert.NotEqual(t, starter.names[0], starter.names[1])
+	assert.Equal(t, [][]int{{1001}, {1002}}, starter.pids)
+
+	// the app keeps running if it cannot be moved to a scope
+	starter.err = errors.New("no systemd")
+	assert.Nil(t, app.Run(nil))
+}
+
+func TestSystemdScopeLauncher_NoID(t *testing.T) {
+	starter := &fakeUnitStarter{}
+	p, err := SystemdScopeLauncher(&fakeLauncher{}, starter).Launch(context.Background(), &Command{Args: []string{"app"}})
+	assert.Nil(t, err)
+	assert.NotNil(t, p)
+	assert.Equal(t, 0, len(starter.names))
+}
+
+func TestSystemdEscape(t *testing.T) {
+	assert.Equal(t, "org.gnome.Calculator", systemdEscape("org.gnome.Calculator"))
+	assert.Equal(t, `wine\x2dPrograms\x2dgame`, systemdEscape("wine-Programs-game"))
+	assert.Equal(t, `\x2ehidden-app\x20name`, systemdEscape(".hidden/app name"))
+}
+
+func TestBusctlScopeArgs(t *testing.T) {
+	args := busctlScopeArgs("app-test-1234.scope", "Test", []int{42})
+	assert.Equal(t, []string{
+		"--user", "call", "org.freedesktop.systemd1", "/org/freedesktop/systemd1",
+		"org.freedesktop.systemd1.Manager", "StartTransientUnit", "ssa(sv)a(sa(sv))",
+		"app-test-1234.scope", "fail", "2", "Description", "s", "Test", "PIDs", "au", "1", "42", "0",
+	}, args)
+}
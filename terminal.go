@@ -0,0 +1,143 @@
+package appie
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// fdoTerminalNames are the terminal apps we look for, in order of preference, if none is configured
+var fdoTerminalNames = []string{"fyneterm", "xfce4-terminal", "gnome-terminal", "org.kde.konsole", "xterm"}
+
+// fdoTerminalExecArgs lists terminals that do not use "-e" to run the rest of their command line
+var fdoTerminalExecArgs = map[string]string{
+	"gnome-terminal": "--",
+	"kgx":            "--",
+	"ptyxis":         "--",
+	"xfce4-terminal": "-x",
+}
+
+// fdoTerminalExecArg returns the argument a terminal needs before the command it should run.
+// This is read from the X-TerminalArgExec key defined by xdg-terminal-exec, if present.
+func fdoTerminalExecArg(entry *DesktopGroup, exe string) string {
+	if entry.Has("X-TerminalArgExec") {
+		return entry.String("X-TerminalArgExec")
+	}
+
+	if arg, ok := fdoTerminalExecArgs[filepath.Base(exe)]; ok {
+		return arg
+	}
+	return "-e"
+}
+
+// fdoPreferredTerminals returns the desktop file IDs listed in the xdg-terminals.list config files.
+// Lists for the current desktops, such as gnome-xdg-terminals.list, are read before the generic list.
+func fdoPreferredTerminals(desktops []string) []string {
+	var names []string
+	for _, desktop := range desktops {
+		names = append(names, strings.ToLower(desktop)+"-xdg-terminals.list")
+	}
+	names = append(names, "xdg-terminals.list")
+
+	var ids []string
+	for _, dir := range fdoLookupXdgConfigDirs() {
+		for _, name := range names {
+			ids = append(ids, readTerminalList(filepath.Join(dir, name))...)
+		}
+	}
+	return ids
+}
+
+func readTerminalList(path string) []string {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var ids []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == '-' {
+			continue // skip comments and excluded entries
+		}
+
+		id := strings.TrimPrefix(line, "+")
+		if pos := strings.IndexByte(id, ':'); pos != -1 {
+			id = id[:pos] // we don't support launching terminal actions
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// terminalPrefix returns the command line needed to run a command inside this terminal app.
+// Field codes such as %i and %c are expanded using the entry of the terminal.
+func (data *fdoApplicationData) terminalPrefix() []string {
+	args, err := SplitExec(data.exec)
+	if err != nil {
+		return nil
+	}
+	args = data.fieldCodes().expand(args, nil)
+	if len(args) == 0 {
+		return nil
+	}
+
+	if data.terminalArgExec != "" {
+		args = append(args, data.terminalArgExec)
+	}
+	return args
+}
+
+// terminal returns the command line used to run an app inside a terminal emulator.
+// If not configured for the provider the xdg-terminal-exec tool or its configuration files are used,
+// falling back to the first of our default terminals that is installed.
+// The search stops with the context error if the context ends before a terminal is found.
+func (f *fdoIconProvider) terminal(ctx context.Context) ([]string, error) {
+	if len(f.opts.terminal) > 0 {
+		return f.opts.terminal, nil
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if path, err := exec.LookPath("xdg-terminal-exec"); err == nil {
+		return []string{path}, nil
+	}
+	for _, id := range fdoPreferredTerminals(f.desktops()) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if app := f.FindAppByID(id); app != nil && app.Installed() {
+			return app.(*fdoApplicationData).terminalPrefix(), nil
+		}
+	}
+
+	for _, name := range fdoTerminalNames {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if app := f.FindAppFromName(name); app != nil {
+			return app.(*fdoApplicationData).terminalPrefix(), nil
+		}
+	}
+	return nil, nil
+}
+
+// terminalCommand wraps the command line passed so that it will run inside a terminal emulator
+func (f *fdoIconProvider) terminalCommand(ctx context.Context, args []string) ([]string, error) {
+	term, err := f.terminal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(term) == 0 {
+		return nil, errors.New("no terminal emulator found to run app")
+	}
+
+	return append(append([]string{}, term...), args...), nil
+}
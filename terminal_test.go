@@ -0,0 +1,77 @@
+package appie
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setTestConfig(t *testing.T, files map[string]string) {
+	dir := t.TempDir()
+	for name, content := range files {
+		err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644)
+		assert.Nil(t, err)
+	}
+
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("XDG_CONFIG_DIRS", filepath.Join(dir, "missing"))
+	t.Setenv("PATH", "")
+}
+
+func TestFdoPreferredTerminals(t *testing.T) {
+	setTestConfig(t, map[string]string{
+		"gnome-xdg-terminals.list": "foot.desktop\n",
+		"xdg-terminals.list":       "# comment\n+kitty.desktop\n-bad.desktop\n\nxterm.desktop:new-window\n",
+	})
+
+	assert.Equal(t, []string{"foot.desktop", "kitty.desktop", "xterm.desktop"}, fdoPreferredTerminals([]string{"GNOME"}))
+	assert.Equal(t, []string{"kitty.desktop", "xterm.desktop"}, fdoPreferredTerminals(nil))
+}
+
+func TestFdoIconProvider_terminalCommand(t *testing.T) {
+	setTestEnv(t)
+	setTestConfig(t, nil)
+
+	provider := NewFDOProvider(WithTerminal("myterm", "-x")).(*fdoIconProvider)
+	cmd, err := provider.terminalCommand(context.Background(), []string{"htop", "-d", "10"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"myterm", "-x", "htop", "-d", "10"}, cmd)
+
+	// applications/xterm.desktop is the only default terminal available
+	provider = NewFDOProvider().(*fdoIconProvider)
+	cmd, err = provider.terminalCommand(context.Background(), []string{"htop"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"xterm", "-e", "htop"}, cmd)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = provider.terminalCommand(ctx, []string{"htop"})
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestFdoIconProvider_terminalFromList(t *testing.T) {
+	setTestEnv(t)
+	// applications/missing.desktop is not installed so will be skipped
+	setTestConfig(t, map[string]string{"xdg-terminals.list": "missing.desktop\napp5.desktop\n"})
+
+	cmd, err := NewFDOProvider().(*fdoIconProvider).terminalCommand(context.Background(), []string{"htop"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"app5", "-e", "htop"}, cmd)
+}
+
+func TestFdoApplicationData_terminalPrefix(t *testing.T) {
+	term := &fdoApplicationData{name: "XTerm", iconName: "xterm", exec: "xterm %i -T %c %F", terminalArgExec: "-e"}
+	assert.Equal(t, []string{"xterm", "--icon", "xterm", "-T", "XTerm", "-e"}, term.terminalPrefix())
+}
+
+func TestFdoTerminalExecArg_EnvPrefix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "terminal.desktop")
+	err := os.WriteFile(path, []byte("[Desktop Entry]\nType=Application\nName=Terminal\nExec=env FOO=1 gnome-terminal\n"), 0o644)
+	assert.Nil(t, err)
+
+	data := newFdoIconData(path, "")
+	assert.Equal(t, "--", data.(*fdoApplicationData).terminalArgExec)
+}
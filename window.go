@@ -0,0 +1,94 @@
+package appie
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// MatchConfidence describes how an app was matched to a window, higher values are more certain.
+type MatchConfidence int
+
+const (
+	// MatchNone means that the app did not match
+	MatchNone MatchConfidence = iota
+	// MatchIDSuffix means the last part of a reverse-DNS app ID matched, ignoring case
+	MatchIDSuffix
+	// MatchExecName means the name of the executable matched, ignoring case
+	MatchExecName
+	// MatchIgnoreCase means the app ID or window class of the app matched, ignoring case
+	MatchIgnoreCase
+	// MatchWindowClass means the window class that the app declares, such as StartupWMClass, matched
+	MatchWindowClass
+	// MatchID means the app ID, such as the desktop file ID without ".desktop", matched
+	MatchID
+)
+
+// AppMatch is an app found for a window along with how confident that match is.
+type AppMatch struct {
+	App        AppData
+	Confidence MatchConfidence
+}
+
+// matchWindowClass returns how well a window class, or Wayland app_id, matches an app.
+// The app is described by its ID, the window class it declares and the executable it runs.
+func matchWindowClass(class, id, appClass, exe string) MatchConfidence {
+	if class == "" {
+		return MatchNone
+	}
+
+	switch {
+	case id != "" && class == id:
+		return MatchID
+	case appClass != "" && class == appClass:
+		return MatchWindowClass
+	case strings.EqualFold(class, id) || strings.EqualFold(class, appClass):
+		return MatchIgnoreCase
+	case exe != "" && strings.EqualFold(class, filepath.Base(exe)):
+		return MatchExecName
+	}
+
+	if pos := strings.LastIndexByte(id, '.'); pos != -1 && strings.EqualFold(class, id[pos+1:]) {
+		return MatchIDSuffix
+	}
+	return MatchNone
+}
+
+// sortAppMatches orders matches so that the most confident is first.
+func sortAppMatches(matches []AppMatch) []AppMatch {
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Confidence > matches[j].Confidence
+	})
+	return matches
+}
+
+// FindAppsMatchingWindow returns the apps that could own a window with the specified WM_CLASS or
+// Wayland app_id, the most likely match is first.
+func (f *fdoIconProvider) FindAppsMatchingWindow(class string) []AppMatch {
+	var matches []AppMatch
+	f.cache.forEachCachedApplication(func(_ string, app AppData) bool {
+		data := app.(*fdoApplicationData)
+		id := strings.TrimSuffix(data.id, ".desktop")
+		if confidence := matchWindowClass(class, id, data.wmClass, data.execProgram()); confidence != MatchNone {
+			matches = append(matches, AppMatch{App: app, Confidence: confidence})
+		}
+		return false
+	})
+
+	return sortAppMatches(matches)
+}
+
+// FindAppsMatchingWindow returns the apps that could own a window with the specified bundle identifier
+// or executable name, the most likely match is first.
+func (m *macOSAppProvider) FindAppsMatchingWindow(class string) []AppMatch {
+	var matches []AppMatch
+	m.cache.forEachCachedApplication(func(name string, app AppData) bool {
+		bundle := app.(*macOSAppBundle)
+		if confidence := matchWindowClass(class, bundle.BundleID, name, bundle.Executable); confidence != MatchNone {
+			matches = append(matches, AppMatch{App: app, Confidence: confidence})
+		}
+		return false
+	})
+
+	return sortAppMatches(matches)
+}
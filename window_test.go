@@ -0,0 +1,79 @@
+package appie
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchWindowClass(t *testing.T) {
+	assert.Equal(t, MatchID, matchWindowClass("org.gnome.Nautilus", "org.gnome.Nautilus", "", "nautilus"))
+	assert.Equal(t, MatchWindowClass, matchWindowClass("Navigator", "firefox", "Navigator", "firefox"))
+	assert.Equal(t, MatchIgnoreCase, matchWindowClass("Firefox", "firefox", "", "/usr/lib/firefox/firefox"))
+	assert.Equal(t, MatchExecName, matchWindowClass("Gimp-2.10", "gimp", "", "/usr/bin/gimp-2.10"))
+	assert.Equal(t, MatchIDSuffix, matchWindowClass("nautilus", "org.gnome.Nautilus", "", "files"))
+	assert.Equal(t, MatchNone, matchWindowClass("other", "org.gnome.Nautilus", "", "nautilus"))
+	assert.Equal(t, MatchNone, matchWindowClass("", "", "", ""))
+}
+
+// applications/org.fyne.Calculator.desktop, applications/app7.desktop and applications/xterm.desktop
+func TestFdoIconProvider_FindAppsMatchingWindow(t *testing.T) {
+	setTestEnv(t)
+	provider := NewFDOProvider()
+
+	matches := provider.FindAppsMatchingWindow("org.fyne.Calculator")
+	assert.Equal(t, 1, len(matches))
+	assert.Equal(t, "Calculator", matches[0].App.Name())
+	assert.Equal(t, MatchID, matches[0].Confidence)
+
+	matches = provider.FindAppsMatchingWindow("calculator")
+	assert.Equal(t, 1, len(matches))
+	assert.Equal(t, MatchIDSuffix, matches[0].Confidence)
+
+	matches = provider.FindAppsMatchingWindow("App7Window")
+	assert.Equal(t, 1, len(matches))
+	assert.Equal(t, "App7", matches[0].App.Name())
+	assert.Equal(t, MatchWindowClass, matches[0].Confidence)
+
+	matches = provider.FindAppsMatchingWindow("XTerm")
+	assert.Equal(t, 1, len(matches))
+	assert.Equal(t, MatchIgnoreCase, matches[0].Confidence)
+
+	assert.Equal(t, 0, len(provider.FindAppsMatchingWindow("unknown")))
+}
+
+// applications/wine/Programs/game.desktop runs "env WINEPREFIX=... wine"
+func TestFdoIconProvider_FindAppsMatchingWindowEnv(t *testing.T) {
+	setTestEnv(t)
+	provider := NewFDOProvider()
+
+	assert.Equal(t, 0, len(provider.FindAppsMatchingWindow("env")))
+	matches := provider.FindAppsMatchingWindow("wine")
+	assert.Equal(t, 1, len(matches))
+	assert.Equal(t, "Wine Game", matches[0].App.Name())
+	assert.Equal(t, MatchExecName, matches[0].Confidence)
+}
+
+func TestMacOSAppProvider_FindAppsMatchingWindow(t *testing.T) {
+	provider := NewMacOSProvider()
+	provider.(*macOSAppProvider).rootDirs = []string{"testdata"}
+
+	matches := provider.FindAppsMatchingWindow("io.fyne.test")
+	assert.Equal(t, 1, len(matches))
+	assert.Equal(t, MatchID, matches[0].Confidence)
+
+	matches = provider.FindAppsMatchingWindow("test")
+	assert.Equal(t, 1, len(matches))
+	assert.Equal(t, MatchIgnoreCase, matches[0].Confidence)
+}
+
+func TestSortAppMatches(t *testing.T) {
+	matches := sortAppMatches([]AppMatch{
+		{Confidence: MatchExecName}, {Confidence: MatchID}, {Confidence: MatchIDSuffix}, {Confidence: MatchWindowClass},
+	})
+
+	assert.Equal(t, MatchID, matches[0].Confidence)
+	assert.Equal(t, MatchWindowClass, matches[1].Confidence)
+	assert.Equal(t, MatchExecName, matches[2].Confidence)
+	assert.Equal(t, MatchIDSuffix, matches[3].Confidence)
+}
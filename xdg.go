@@ -0,0 +1,62 @@
+package appie
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// fdoLookupXdgDataDirs returns the data directories to search in order of preference.
+// This is XDG_DATA_HOME followed by XDG_DATA_DIRS, using the default locations where they are not set.
+func fdoLookupXdgDataDirs() []string {
+	return fdoXdgBaseDirs("XDG_DATA_HOME", filepath.Join(".local", "share"),
+		"XDG_DATA_DIRS", []string{"/usr/local/share", "/usr/share"})
+}
+
+// fdoLookupXdgConfigDirs returns the config directories to search in order of preference.
+// This is XDG_CONFIG_HOME followed by XDG_CONFIG_DIRS, using the default locations where they are not set.
+func fdoLookupXdgConfigDirs() []string {
+	return fdoXdgBaseDirs("XDG_CONFIG_HOME", ".config", "XDG_CONFIG_DIRS", []string{"/etc/xdg"})
+}
+
+// fdoXdgBaseDirs implements the XDG Base Directory lookup for a user directory and a list of system directories.
+// The homeDir is relative to the user's home and is used if homeVar is not set, likewise dirsDefault
+// is used if dirsVar is empty. Relative paths are invalid so they are ignored, as are duplicates.
+func fdoXdgBaseDirs(homeVar, homeDir, dirsVar string, dirsDefault []string) []string {
+	var dirs []string
+	home := os.Getenv(homeVar)
+	if !filepath.IsAbs(home) {
+		if userHome, err := os.UserHomeDir(); err == nil {
+			home = filepath.Join(userHome, homeDir)
+		}
+	}
+	dirs = appendXdgDir(dirs, home)
+
+	var systemDirs []string
+	for _, dir := range filepath.SplitList(os.Getenv(dirsVar)) {
+		if filepath.IsAbs(dir) {
+			systemDirs = append(systemDirs, dir)
+		}
+	}
+	if len(systemDirs) == 0 {
+		systemDirs = dirsDefault
+	}
+
+	for _, dir := range systemDirs {
+		dirs = appendXdgDir(dirs, dir)
+	}
+	return dirs
+}
+
+func appendXdgDir(dirs []string, dir string) []string {
+	if dir == "" {
+		return dirs
+	}
+
+	dir = filepath.Clean(dir)
+	for _, existing := range dirs {
+		if existing == dir {
+			return dirs
+		}
+	}
+	return append(dirs, dir)
+}
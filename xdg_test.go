@@ -0,0 +1,43 @@
+package appie
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFdoLookupXdgDataDirs(t *testing.T) {
+	home, _ := filepath.Abs(filepath.Join("testdata", "local"))
+	system, _ := filepath.Abs("testdata")
+	t.Setenv("XDG_DATA_HOME", home)
+	t.Setenv("XDG_DATA_DIRS", system+string(os.PathListSeparator)+"relative"+string(os.PathListSeparator)+home+string(os.PathSeparator))
+	assert.Equal(t, []string{home, system}, fdoLookupXdgDataDirs())
+
+	t.Setenv("XDG_DATA_DIRS", "")
+	dirs := fdoLookupXdgDataDirs()
+	assert.Equal(t, home, dirs[0])
+	assert.Equal(t, []string{"/usr/local/share", "/usr/share"}, filepathsToSlash(dirs[1:]))
+}
+
+func TestFdoLookupXdgConfigDirs(t *testing.T) {
+	userHome, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("No home directory", err)
+	}
+	t.Setenv("XDG_CONFIG_HOME", "relative")
+	t.Setenv("XDG_CONFIG_DIRS", "")
+
+	dirs := fdoLookupXdgConfigDirs()
+	assert.Equal(t, filepath.Join(userHome, ".config"), dirs[0])
+	assert.Equal(t, []string{"/etc/xdg"}, filepathsToSlash(dirs[1:]))
+}
+
+func filepathsToSlash(paths []string) []string {
+	slashed := make([]string, len(paths))
+	for i, p := range paths {
+		slashed[i] = filepath.ToSlash(p)
+	}
+	return slashed
+}